@@ -12,7 +12,6 @@ require (
 	github.com/spf13/viper v1.9.0
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/crypto v0.0.0-20210817164053-32db794688a5
-	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 	gopkg.in/mail.v2 v2.3.1
 )
 
@@ -0,0 +1,63 @@
+package anomaly
+
+import (
+	"math"
+
+	"github.com/nebisin/goExpense/internal/store"
+)
+
+const (
+	// minSamples is the number of similar transactions needed
+	// before an amount can be considered as unusual.
+	minSamples = 5
+	// deviations is how many standard deviations an amount must be
+	// above the mean of the similar transactions to be unusual.
+	deviations = 3
+	// spikeFactor is how many times the trailing daily average
+	// the spending of a day must be to be a spike.
+	spikeFactor = 3
+)
+
+// IsUnusualAmount reports whether the amount is far above the amounts
+// of the similar transactions.
+func IsUnusualAmount(amount float64, similar []float64) bool {
+	if len(similar) < minSamples {
+		return false
+	}
+
+	var mean float64
+	for _, value := range similar {
+		mean += value
+	}
+	mean /= float64(len(similar))
+
+	var variance float64
+	for _, value := range similar {
+		variance += math.Pow(value-mean, 2)
+	}
+	deviation := math.Sqrt(variance / float64(len(similar)))
+
+	// Without the second condition every amount slightly above a list
+	// of equal amounts would be flagged because the deviation is zero.
+	return amount > mean+deviations*deviation && amount > 2*mean
+}
+
+// IsSpendingSpike reports whether the spending of a day is far above the
+// daily average of the trailing statistics over the given number of days.
+func IsSpendingSpike(spending float64, trailing []*store.Statistic, days int) bool {
+	if days <= 0 || len(trailing) < minSamples {
+		return false
+	}
+
+	var total float64
+	for _, stat := range trailing {
+		total += stat.Spending
+	}
+
+	average := total / float64(days)
+	if average <= 0 {
+		return false
+	}
+
+	return spending > spikeFactor*average
+}
@@ -0,0 +1,56 @@
+package anomaly_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/anomaly"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUnusualAmount(t *testing.T) {
+	similar := []float64{20, 25, 22, 18, 30, 24}
+
+	t.Run("unusual amount case", func(t *testing.T) {
+		require.True(t, anomaly.IsUnusualAmount(250, similar))
+	})
+
+	t.Run("usual amount case", func(t *testing.T) {
+		require.False(t, anomaly.IsUnusualAmount(28, similar))
+	})
+
+	t.Run("equal amounts case", func(t *testing.T) {
+		require.False(t, anomaly.IsUnusualAmount(11, []float64{10, 10, 10, 10, 10}))
+		require.True(t, anomaly.IsUnusualAmount(25, []float64{10, 10, 10, 10, 10}))
+	})
+
+	t.Run("not enough samples case", func(t *testing.T) {
+		require.False(t, anomaly.IsUnusualAmount(1000, similar[:2]))
+	})
+}
+
+func TestIsSpendingSpike(t *testing.T) {
+	day := time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	trailing := []*store.Statistic{}
+	for i := 1; i <= 10; i++ {
+		trailing = append(trailing, &store.Statistic{Date: day.AddDate(0, 0, -i), Spending: 30})
+	}
+
+	t.Run("spike case", func(t *testing.T) {
+		require.True(t, anomaly.IsSpendingSpike(100, trailing, 10))
+	})
+
+	t.Run("no spike case", func(t *testing.T) {
+		require.False(t, anomaly.IsSpendingSpike(80, trailing, 10))
+	})
+
+	t.Run("days without statistics lower the average", func(t *testing.T) {
+		require.True(t, anomaly.IsSpendingSpike(80, trailing, 30))
+	})
+
+	t.Run("not enough history case", func(t *testing.T) {
+		require.False(t, anomaly.IsSpendingSpike(1000, trailing[:2], 30))
+	})
+}
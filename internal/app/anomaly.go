@@ -0,0 +1,110 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/anomaly"
+	"github.com/nebisin/goExpense/internal/store"
+)
+
+const (
+	duplicateWindow = 24 * time.Hour
+	spikeWindowDays = 30
+)
+
+// detectAnomalies flags the transaction if it looks unusual for the account and
+// sends the new flags to the account members when the alerts are enabled.
+func (s *server) detectAnomalies(ts *store.Transaction, account *store.Account) error {
+	var flags []*store.Anomaly
+
+	if ts.Type == "expense" {
+		similar, err := s.models.Transactions.GetSimilarAmounts(ts)
+		if err != nil {
+			return err
+		}
+
+		if anomaly.IsUnusualAmount(ts.Amount, similar) {
+			flags = append(flags, &store.Anomaly{
+				Kind:    store.AnomalyUnusualAmount,
+				Message: fmt.Sprintf("%q is far above the usual amount of similar expenses", ts.Title),
+			})
+		}
+
+		// The window ends at the start of the day, so that the spending
+		// of the day itself is not a part of its baseline.
+		day := ts.Payday.UTC().Truncate(24 * time.Hour)
+
+		stat, err := s.models.Statistics.GetByDate(ts.AccountID, day)
+		if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+			return err
+		}
+
+		trailing, err := s.models.Statistics.GetAll(ts.AccountID, day.AddDate(0, 0, -spikeWindowDays), day)
+		if err != nil {
+			return err
+		}
+
+		if stat != nil && anomaly.IsSpendingSpike(stat.Spending, trailing, spikeWindowDays) {
+			flags = append(flags, &store.Anomaly{
+				Kind:    store.AnomalySpendingSpike,
+				Message: fmt.Sprintf("the spending on %s is far above the daily average", day.Format("2006-01-02")),
+			})
+		}
+	}
+
+	duplicates, err := s.models.Transactions.GetDuplicateIDs(ts, duplicateWindow)
+	if err != nil {
+		return err
+	}
+
+	if len(duplicates) != 0 {
+		flags = append(flags, &store.Anomaly{
+			Kind:    store.AnomalyDuplicate,
+			Message: fmt.Sprintf("%q looks like a duplicate of the transaction %d", ts.Title, duplicates[0]),
+		})
+	}
+
+	var messages []string
+
+	for _, flag := range flags {
+		flag.AccountID = ts.AccountID
+		flag.TransactionID = ts.ID
+
+		if err := s.models.Anomalies.Insert(flag); err != nil {
+			if errors.Is(err, store.ErrAnomalyExists) {
+				continue
+			}
+			return err
+		}
+
+		messages = append(messages, flag.Message)
+	}
+
+	if len(messages) == 0 || !s.config.AnomalyAlerts {
+		return nil
+	}
+
+	users, err := s.models.Accounts.GetUsers(ts.AccountID)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"accountTitle": account.Title,
+		"messages":     messages,
+	}
+
+	// A failed email does not keep the alert from the other members.
+	for _, user := range users {
+		if err := s.mailer.Send(user.Email, "anomaly_alert.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"account_id": ts.AccountID,
+				"user_id":    user.ID,
+			}).WithError(err).Error("anomaly alert email error")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+var (
+	// accountAttemptPolicy limits the password and the code guesses against an account.
+	// The owner of a locked account gets an email with a link to unlock it.
+	accountAttemptPolicy = cache.AttemptPolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        15 * time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: time.Hour,
+		Window:          24 * time.Hour,
+	}
+
+	// ipAttemptPolicy limits the guesses from an IP address against any account
+	// or token. It is looser since many users can share an IP address.
+	ipAttemptPolicy = cache.AttemptPolicy{
+		FreeAttempts: 20,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Hour,
+		Window:       time.Hour,
+	}
+)
+
+func accountAttemptKey(email string) string {
+	return "account." + strings.ToLower(email)
+}
+
+func ipAttemptKey(action string, r *http.Request) string {
+	return action + ".ip." + clientIP(r)
+}
+
+// checkAttempts responds with the time to wait if any of the keys is blocked. The attempts
+// are allowed when the cache is not available so that the users can still log in.
+func (s *server) checkAttempts(w http.ResponseWriter, r *http.Request, keys ...string) bool {
+	blocked, err := s.cache.Attempts.Blocked(keys...)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"request_method": r.Method,
+			"request_url":    r.URL.String(),
+			"client_ip":      clientIP(r),
+		}).WithError(err).Error("cache error")
+		return true
+	}
+
+	if blocked > 0 {
+		response.TooManyAttemptsResponse(w, r, blocked)
+		return false
+	}
+
+	return true
+}
+
+func (s *server) failAttempt(r *http.Request, key string, policy cache.AttemptPolicy) int64 {
+	failures, err := s.cache.Attempts.Fail(key, policy)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"request_method": r.Method,
+			"request_url":    r.URL.String(),
+			"client_ip":      clientIP(r),
+		}).WithError(err).Error("cache error")
+	}
+
+	return failures
+}
+
+// failAccountAttempt counts a failed attempt against the account of the user
+// and the IP address, and notifies the user if the account is locked out.
+func (s *server) failAccountAttempt(r *http.Request, user *store.User, action string) {
+	s.failAttempt(r, ipAttemptKey(action, r), ipAttemptPolicy)
+
+	failures := s.failAttempt(r, accountAttemptKey(user.Email), accountAttemptPolicy)
+
+	// The attempts are blocked while the account is locked, so a failure which
+	// locks the account out starts a new lockout and the email is sent for each.
+	if !accountAttemptPolicy.IsLockout(failures) {
+		return
+	}
+
+	token, err := s.models.Tokens.New(user.ID, accountAttemptPolicy.LockoutDuration, store.ScopeUnlock)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"request_method": r.Method,
+			"request_url":    r.URL.String(),
+			"client_ip":      clientIP(r),
+		}).WithError(err).Error("unlock token error")
+		return
+	}
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"unlockToken": token.Plaintext,
+			"ip":          clientIP(r),
+		}
+
+		if err := s.mailer.Send(user.Email, "account_locked.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+}
+
+func (s *server) resetAttempts(r *http.Request, keys ...string) {
+	if err := s.cache.Attempts.Reset(keys...); err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"request_method": r.Method,
+			"request_url":    r.URL.String(),
+			"client_ip":      clientIP(r),
+		}).WithError(err).Error("cache error")
+	}
+}
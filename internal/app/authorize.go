@@ -0,0 +1,45 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+var errNotPermitted = errors.New("not permitted")
+
+// authorizeAccount returns the membership of the authenticated user in the account
+// if the role of the user grants the permission. It returns store.ErrRecordNotFound
+// when the user is not a member so that the account is not exposed to outsiders.
+// The accounts an API token is not restricted to are treated the same way.
+func (s *server) authorizeAccount(r *http.Request, accountID int64, permission string) (*store.Member, error) {
+	user := s.contextGetUser(r)
+
+	if apiToken := s.contextGetAPIToken(r); apiToken != nil && !apiToken.AllowsAccount(accountID) {
+		return nil, store.ErrRecordNotFound
+	}
+
+	member, err := s.models.Accounts.GetMember(accountID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !member.Can(permission) {
+		return nil, errNotPermitted
+	}
+
+	return member, nil
+}
+
+func (s *server) authorizationErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrRecordNotFound):
+		response.NotFoundResponse(w, r)
+	case errors.Is(err, errNotPermitted):
+		response.NotPermittedResponse(w, r)
+	default:
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
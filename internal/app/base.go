@@ -4,7 +4,6 @@ import (
 	"database/sql"
 	"os"
 	"sync"
-	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/nebisin/goExpense/pkg/config"
@@ -13,31 +12,30 @@ import (
 	"github.com/nebisin/goExpense/internal/cache"
 	"github.com/nebisin/goExpense/internal/mailer"
 	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/nebisin/goExpense/pkg/oidc"
+	"github.com/nebisin/goExpense/pkg/realip"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/time/rate"
 )
 
 const version = "1.0.0"
 
 type server struct {
-	router  *mux.Router
-	logger  *logrus.Logger
-	config  config.Config
-	db      *sql.DB
-	rdb     *redis.Client
-	cache   *cache.Cache
-	models  *store.Models
-	wg      sync.WaitGroup
-	mailer  mailer.Mailer
-	limiter struct {
-		mu      sync.Mutex
-		clients map[string]*client
-	}
-}
-
-type client struct {
-	limiter  *rate.Limiter
-	lastSeen time.Time
+	router     *mux.Router
+	logger     *logrus.Logger
+	config     config.Config
+	db         *sql.DB
+	rdb        *redis.Client
+	cache      *cache.Cache
+	models     *store.Models
+	tokenMaker auth.Maker
+	providers  map[string]*oidc.Provider
+	wg         sync.WaitGroup
+	mailer     mailer.Mailer
+	// passwordPolicy is the rules of the new passwords.
+	passwordPolicy *auth.PasswordPolicy
+	rateLimits     *rateLimitPolicies
+	realIP         *realip.Resolver
 }
 
 func NewServer() *server {
@@ -55,6 +53,35 @@ func (s *server) Run() {
 	}
 	s.config = cfg
 
+	tokenMaker, err := s.newTokenMaker()
+	if err != nil {
+		s.logger.WithError(err).Fatal("something went wrong while loading the token keys")
+	}
+	s.tokenMaker = tokenMaker
+
+	passwordPolicy, err := s.newPasswordPolicy()
+	if err != nil {
+		s.logger.WithError(err).Fatal("something went wrong while loading the breached passwords")
+	}
+	s.passwordPolicy = passwordPolicy
+
+	realIP, err := realip.NewResolver(s.config.Proxies.Trusted, s.config.Proxies.Header)
+	if err != nil {
+		s.logger.WithError(err).Fatal("something went wrong while loading the trusted proxies")
+	}
+	s.realIP = realIP
+
+	rateLimits, err := newRateLimitPolicies(s.config.RateLimitPolicies)
+	if err != nil {
+		s.logger.WithError(err).Fatal("something went wrong while loading the rate limit policies")
+	}
+	s.rateLimits = rateLimits
+
+	s.providers = make(map[string]*oidc.Provider, len(s.config.OIDCProviders))
+	for _, provider := range s.config.OIDCProviders {
+		s.providers[provider.Name] = oidc.NewProvider(provider, nil)
+	}
+
 	s.mailer = mailer.New(s.config.SMTP.Host, s.config.SMTP.Port, s.config.SMTP.Username, s.config.SMTP.Password, s.config.SMTP.Sender)
 
 	s.logger.Info("we are connecting the database")
@@ -76,29 +103,31 @@ func (s *server) Run() {
 
 	s.setupRoutes()
 
-	s.setupLimiter()
+	s.setupTrashPurge()
+
+	s.setupExchangeRates()
 
 	if err := s.serve(); err != nil {
 		s.logger.WithError(err).Fatal("an error occurred while starting the server")
 	}
 }
 
-func (s *server) setupLimiter() {
-	s.limiter.clients = make(map[string]*client)
+// newTokenMaker returns a maker of the configured token type with the keys in the keys
+// path. The symmetric key is used when the keys path is not configured.
+func (s *server) newTokenMaker() (auth.Maker, error) {
+	var keySet *auth.KeySet
 
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-
-			s.limiter.mu.Lock()
-
-			for ip, client := range s.limiter.clients {
-				if time.Since(client.lastSeen) > 3*time.Minute {
-					delete(s.limiter.clients, ip)
-				}
-			}
+	if s.config.TokenKeysPath != "" {
+		keys, err := auth.LoadKeys(s.config.TokenKeysPath)
+		if err != nil {
+			return nil, err
+		}
 
-			s.limiter.mu.Unlock()
+		keySet, err = auth.NewKeySet(s.config.TokenSigningKeyID, keys...)
+		if err != nil {
+			return nil, err
 		}
-	}()
+	}
+
+	return auth.NewMaker(s.config.TokenType, s.config.JwtSecret, keySet, s.config.TokenIssuer, s.config.TokenAudience)
 }
@@ -3,12 +3,17 @@ package app
 import (
 	"context"
 	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/realip"
 	"net/http"
 )
 
 type contextKey string
 
-const userContextKey = contextKey("user")
+const (
+	userContextKey     = contextKey("user")
+	sessionContextKey  = contextKey("session")
+	apiTokenContextKey = contextKey("apiToken")
+)
 
 func (s *server) contextSetUser(r *http.Request, user *store.User) *http.Request {
 	ctx := context.WithValue(r.Context(), userContextKey, user)
@@ -23,3 +28,41 @@ func (s *server) contextGetUser(r *http.Request) *store.User {
 
 	return user
 }
+
+func (s *server) contextSetSessionID(r *http.Request, sessionID int64) *http.Request {
+	ctx := context.WithValue(r.Context(), sessionContextKey, sessionID)
+	return r.WithContext(ctx)
+}
+
+// contextGetSessionID returns the session of the authenticated user
+// or 0 if the request is not authenticated.
+func (s *server) contextGetSessionID(r *http.Request) int64 {
+	sessionID, _ := r.Context().Value(sessionContextKey).(int64)
+	return sessionID
+}
+
+func (s *server) contextSetAPIToken(r *http.Request, token *store.APIToken) *http.Request {
+	ctx := context.WithValue(r.Context(), apiTokenContextKey, token)
+	return r.WithContext(ctx)
+}
+
+// contextGetAPIToken returns the API token the request is authenticated
+// with or nil if the request is not made with an API token.
+func (s *server) contextGetAPIToken(r *http.Request) *store.APIToken {
+	token, _ := r.Context().Value(apiTokenContextKey).(*store.APIToken)
+	return token
+}
+
+// contextGetActor returns the authenticated user with the address
+// of the request to record the changes made by the user.
+func (s *server) contextGetActor(r *http.Request) store.Actor {
+	return store.Actor{
+		UserID: s.contextGetUser(r).ID,
+		IP:     clientIP(r),
+	}
+}
+
+// clientIP returns the address of the client behind the trusted proxies.
+func clientIP(r *http.Request) string {
+	return realip.FromRequest(r)
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// etag returns the entity tag of the version of the record.
+func etag(entity string, id int64, version int) string {
+	return fmt.Sprintf(`"%s-%d-%d"`, entity, id, version)
+}
+
+// matchETag reports whether the header lists the entity tag or is "*".
+func matchETag(header string, tag string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch reports whether the record with the entity tag can be changed by the request.
+// The requests without the If-Match header can change any version of the record.
+func checkIfMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	return matchETag(header, tag)
+}
+
+// checkIfNoneMatch reports whether the client already has the record with the entity tag.
+func checkIfNoneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	return matchETag(header, tag)
+}
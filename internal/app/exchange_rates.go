@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/exchange"
+)
+
+const defaultExchangeRatesInterval = 12 * time.Hour
+
+// setupExchangeRates stores the configured exchange rates and, if an exchange rate API is
+// configured, keeps the rates up to date from it. The reports convert the amounts with the
+// stored rates, so a currency without a rate cannot be converted.
+func (s *server) setupExchangeRates() {
+	cfg := s.config.ExchangeRates
+
+	rates, err := exchange.ParseRates(cfg.Rates)
+	if err != nil {
+		s.logger.WithError(err).Fatal("something went wrong while parsing the exchange rates")
+	}
+
+	if len(rates) > 0 {
+		if err := s.models.UpsertRatesTX(store.Rates(rates)); err != nil {
+			s.logger.WithError(err).Fatal("something went wrong while storing the exchange rates")
+		}
+	}
+
+	if cfg.URL == "" {
+		return
+	}
+
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = defaultExchangeRatesInterval
+	}
+
+	go func() {
+		for {
+			if err := s.updateExchangeRates(); err != nil {
+				s.logger.WithError(err).Error("an error occurred while updating the exchange rates")
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func (s *server) updateExchangeRates() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rates, err := exchange.Fetch(ctx, nil, s.config.ExchangeRates.URL, store.BaseCurrency)
+	if err != nil {
+		return err
+	}
+
+	if err := s.models.UpsertRatesTX(store.Rates(rates)); err != nil {
+		return err
+	}
+
+	s.logger.WithField("currencies", len(rates)).Info("updated the exchange rates")
+
+	return nil
+}
@@ -45,7 +45,7 @@ func (s *server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 		account.TotalIncome = input.InitialBalance
 	}
 
-	err := s.models.CreateAccountTX(&account)
+	err := s.models.CreateAccountTX(&account, s.contextGetActor(r))
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
@@ -75,10 +75,16 @@ func (s *server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := s.contextGetUser(r)
+	if _, err := s.authorizeAccount(r, account.ID, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
 
-	if account.OwnerID != user.ID {
-		response.NotFoundResponse(w, r)
+	tag := etag("account", account.ID, account.Version)
+	w.Header().Set("ETag", tag)
+
+	if checkIfNoneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
@@ -96,9 +102,27 @@ func (s *server) handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := s.contextGetUser(r)
+	if _, err := s.authorizeAccount(r, id, store.PermissionDeleteAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
 
-	err = s.models.Accounts.Delete(id, user.ID)
+	account, err := s.models.Accounts.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if !checkIfMatch(r, etag("account", account.ID, account.Version)) {
+		response.PreconditionFailedResponse(w, r)
+		return
+	}
+
+	err = s.models.DeleteAccountTX(account, s.contextGetActor(r))
 	if err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
 			response.NotFoundResponse(w, r)
@@ -132,10 +156,13 @@ func (s *server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := s.contextGetUser(r)
+	if _, err := s.authorizeAccount(r, account.ID, store.PermissionUpdateAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
 
-	if user.ID != account.OwnerID {
-		response.NotFoundResponse(w, r)
+	if !checkIfMatch(r, etag("account", account.ID, account.Version)) {
+		response.PreconditionFailedResponse(w, r)
 		return
 	}
 
@@ -154,6 +181,8 @@ func (s *server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	oldAccount := *account
+
 	if input.Title != nil {
 		account.Title = *input.Title
 	}
@@ -162,7 +191,7 @@ func (s *server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 		account.Description = *input.Description
 	}
 
-	err = s.models.Accounts.Update(account)
+	err = s.models.UpdateAccountTX(account, oldAccount, s.contextGetActor(r))
 	if err != nil {
 		if errors.Is(err, store.ErrEditConflict) {
 			response.EditConflictResponse(w, r)
@@ -173,6 +202,8 @@ func (s *server) handleUpdateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", etag("account", account.ID, account.Version))
+
 	if err := response.JSON(w, http.StatusOK, response.Envelope{"account": account}); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 	}
@@ -219,6 +250,7 @@ func (s *server) handleAddUser(w http.ResponseWriter, r *http.Request) {
 
 	var input struct {
 		Email string `json:"email" validate:"required,email"`
+		Role  string `json:"role,omitempty" validate:"omitempty,oneof='admin' 'editor' 'contributor' 'viewer'"`
 	}
 
 	err = request.ReadJSON(w, r, &input)
@@ -232,8 +264,124 @@ func (s *server) handleAddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	account, err := s.models.Accounts.Get(id)
+	member, err := s.authorizeAccount(r, id, store.PermissionManageMembers)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if input.Role == "" {
+		input.Role = store.RoleContributor
+	}
+
+	if input.Role == store.RoleAdmin && member.Role != store.RoleOwner {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	user, err := s.models.Users.GetByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.inviteUser(w, r, member, id, input.Email, input.Role)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	err = s.models.AddMemberTX(id, user.ID, input.Role, s.contextGetActor(r))
 	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "user is added to the account"})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleGetUsers(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, id, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	users, err := s.models.Accounts.GetUsers(id)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"users": users})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleUpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	userID, err := strconv.ParseInt(vars["userID"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" validate:"required,oneof='admin' 'editor' 'contributor' 'viewer'"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	member, err := s.authorizeAccount(r, id, store.PermissionManageMembers)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	target, err := s.models.Accounts.GetMember(id, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if target.Role == store.RoleOwner {
+		response.FailedValidationResponse(w, r, map[string]string{"role": "the role of the owner cannot be changed"})
+		return
+	}
+
+	// Only the owner can grant or revoke the admin role.
+	if (target.Role == store.RoleAdmin || input.Role == store.RoleAdmin) && member.Role != store.RoleOwner {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	if err := s.models.UpdateMemberRoleTX(id, target, input.Role, s.contextGetActor(r)); err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
 			response.NotFoundResponse(w, r)
 		} else {
@@ -242,32 +390,91 @@ func (s *server) handleAddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	auth := s.contextGetUser(r)
-	if auth.ID != account.OwnerID {
+	target.Role = input.Role
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"user": target}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRemoveUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
 		response.NotFoundResponse(w, r)
 		return
 	}
 
-	user, err := s.models.Users.GetByEmail(input.Email)
+	userID, err := strconv.ParseInt(vars["userID"], 10, 64)
 	if err != nil {
-		// TODO: If email is not exists in database send an invitation
 		response.NotFoundResponse(w, r)
 		return
 	}
 
-	err = s.models.Accounts.AddUser(user.ID, account.ID)
+	member, err := s.authorizeAccount(r, id, store.PermissionManageMembers)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	target, err := s.models.Accounts.GetMember(id, userID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if target.Role == store.RoleOwner {
+		response.FailedValidationResponse(w, r, map[string]string{"userID": "the owner cannot be removed from the account"})
+		return
+	}
+
+	// Only the owner can remove an admin.
+	if target.Role == store.RoleAdmin && member.Role != store.RoleOwner {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	account, err := s.models.Accounts.Get(id)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
-	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "user is added to the account"})
+	if err := s.models.RemoveMemberTX(id, target, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"accountTitle": account.Title,
+			"removerName":  member.Name,
+		}
+
+		if err := s.mailer.Send(target.Email, "member_removed.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "user is removed from the account"})
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 	}
 }
 
-func (s *server) handleGetUsers(w http.ResponseWriter, r *http.Request) {
+func (s *server) handleLeaveAccount(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.ParseInt(vars["id"], 10, 64)
 	if err != nil {
@@ -275,28 +482,136 @@ func (s *server) handleGetUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	users, err := s.models.Accounts.GetUsers(id)
+	member, err := s.authorizeAccount(r, id, store.PermissionReadAccount)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if member.Role == store.RoleOwner {
+		response.FailedValidationResponse(w, r, map[string]string{"account": "the owner must transfer the ownership before leaving the account"})
+		return
+	}
+
+	account, err := s.models.Accounts.Get(id)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
-	user := s.contextGetUser(r)
+	owner, err := s.models.Users.Get(account.OwnerID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := s.models.RemoveMemberTX(id, member, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
 
-	isMember := false
-	for _, value := range users {
-		if value.ID == user.ID {
-			isMember = true
-			break
+	s.background(func() {
+		data := map[string]interface{}{
+			"accountTitle": account.Title,
+			"memberName":   member.Name,
 		}
+
+		if err := s.mailer.Send(owner.Email, "member_left.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "you left the account"})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
 	}
-	if !isMember {
+}
+
+func (s *server) handleTransferOwnership(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
 		response.NotFoundResponse(w, r)
 		return
 	}
 
-	err = response.JSON(w, http.StatusOK, response.Envelope{"users": users})
+	var input struct {
+		UserID int64 `json:"userID" validate:"required"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	member, err := s.authorizeAccount(r, id, store.PermissionTransferAccount)
 	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if input.UserID == member.ID {
+		response.FailedValidationResponse(w, r, map[string]string{"userID": "you are already the owner of the account"})
+		return
+	}
+
+	target, err := s.models.Accounts.GetMember(id, input.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.FailedValidationResponse(w, r, map[string]string{"userID": "must be a member of the account"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	account, err := s.models.Accounts.Get(id)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := s.models.TransferOwnershipTX(account, target.ID, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrEditConflict) {
+			response.EditConflictResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"accountTitle":  account.Title,
+			"previousOwner": member.Name,
+			"newOwner":      target.Name,
+		}
+
+		for _, email := range []string{target.Email, member.Email} {
+			if err := s.mailer.Send(email, "ownership_transferred.tmpl", data); err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"request_method": r.Method,
+					"request_url":    r.URL.String(),
+					"client_ip":      clientIP(r),
+				}).WithError(err).Error("background email error")
+			}
+		}
+	})
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"account": account}); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 	}
 }
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+func (s *server) handleListActivityByAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, id, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Actor  int64  `json:"actor" validate:"gte=0"`
+		Entity string `json:"entity" validate:"omitempty,oneof='account' 'transaction' 'member'"`
+		store.Filters
+	}
+
+	qs := r.URL.Query()
+
+	input.Actor = int64(request.ReadInt(qs, "actor", 0))
+	input.Entity = request.ReadString(qs, "entity", "")
+
+	input.Filters.Page = request.ReadInt(qs, "page", 1)
+	input.Filters.Limit = request.ReadInt(qs, "limit", 20)
+
+	// The activity is always listed from the newest to the oldest.
+	input.Filters.Sort = "-id"
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	logs, err := s.models.AuditLogs.GetAllByAccountID(id, input.Actor, input.Entity, input.Filters)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"activity": logs}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+func (s *server) handleListAnomaliesByAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, id, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Dismissed bool
+		store.Filters
+	}
+
+	qs := r.URL.Query()
+
+	input.Dismissed = request.ReadBool(qs, "dismissed", false)
+
+	input.Filters.Page = request.ReadInt(qs, "page", 1)
+	input.Filters.Limit = request.ReadInt(qs, "limit", 20)
+
+	// Anomalies are always listed from the newest to the oldest.
+	input.Filters.Sort = "-id"
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	anomalies, err := s.models.Anomalies.GetAllByAccountID(id, input.Dismissed, input.Filters)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"anomalies": anomalies}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleDismissAnomaly(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	anomalyID, err := strconv.ParseInt(vars["anomalyID"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, id, store.PermissionEditAnyTransaction); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if err := s.models.Anomalies.Dismiss(anomalyID, id); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "anomaly successfully dismissed"})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
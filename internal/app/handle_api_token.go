@@ -0,0 +1,133 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const apiTokenTouchInterval = time.Minute
+
+func (s *server) handleCreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name       string     `json:"name" validate:"required,max=100"`
+		Scopes     []string   `json:"scopes" validate:"required,min=1,unique,dive,oneof='transactions:read' 'transactions:write' 'accounts:read' 'accounts:write'"`
+		AccountIDs []int64    `json:"accountIDs,omitempty" validate:"unique,max=100"`
+		Expiry     *time.Time `json:"expiry,omitempty"`
+		Password   string     `json:"password" validate:"required,max=72"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	if input.Expiry != nil && !input.Expiry.After(time.Now()) {
+		response.FailedValidationResponse(w, r, map[string]string{"expiry": "must be in the future"})
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	// A token outlives the session, so the password is confirmed
+	// in case the session is used by someone else.
+	if !s.confirmPassword(w, r, user.ID, input.Password) {
+		return
+	}
+
+	for i, accountID := range input.AccountIDs {
+		if _, err := s.authorizeAccount(r, accountID, store.PermissionReadAccount); err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				response.FailedValidationResponse(w, r, map[string]string{fmt.Sprintf("accountIDs[%d]", i): "does not exist"})
+			} else {
+				s.authorizationErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	token := &store.APIToken{
+		UserID:     user.ID,
+		Name:       input.Name,
+		Scopes:     input.Scopes,
+		AccountIDs: input.AccountIDs,
+		Expiry:     input.Expiry,
+	}
+
+	if err := s.models.APITokens.New(token); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	// The plaintext of the token is only shown in this response.
+	if err := response.JSON(w, http.StatusCreated, response.Envelope{"apiToken": token}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleListAPITokens(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+
+	tokens, err := s.models.APITokens.GetAllByUserID(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"apiTokens": tokens}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	if err := s.models.APITokens.Revoke(id, user.ID); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	env := response.Envelope{"message": "the API token is successfully revoked"}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) touchAPIToken(r *http.Request, token *store.APIToken) {
+	if token.LastUsedAt != nil && time.Since(*token.LastUsedAt) < apiTokenTouchInterval {
+		return
+	}
+
+	s.background(func() {
+		if err := s.models.APITokens.Touch(token.ID, apiTokenTouchInterval); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background API token error")
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+type batchOperationInput struct {
+	Action      string     `json:"action" validate:"required,oneof='create' 'update' 'delete'"`
+	ID          int64      `json:"id,omitempty" validate:"required_unless=Action create"`
+	AccountID   int64      `json:"accountID,omitempty" validate:"required_if=Action create"`
+	Type        *string    `json:"type,omitempty" validate:"required_if=Action create,omitempty,oneof='expense' 'income'"`
+	Title       *string    `json:"title,omitempty" validate:"required_if=Action create,omitempty,min=3,max=180"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=1000"`
+	Tags        []string   `json:"tags,omitempty" validate:"unique"`
+	Amount      *float64   `json:"amount,omitempty" validate:"required_if=Action create"`
+	Payday      *time.Time `json:"payday,omitempty" validate:"required_if=Action create"`
+}
+
+// apply sets the given fields of the input on the transaction.
+func (input *batchOperationInput) apply(ts *store.Transaction) {
+	if input.Type != nil {
+		ts.Type = *input.Type
+	}
+
+	if input.Title != nil {
+		ts.Title = *input.Title
+	}
+
+	if input.Description != nil {
+		ts.Description = *input.Description
+	}
+
+	if input.Tags != nil {
+		ts.Tags = input.Tags
+	}
+
+	if input.Amount != nil {
+		ts.Amount = *input.Amount
+	}
+
+	if input.Payday != nil {
+		ts.Payday = *input.Payday
+	}
+}
+
+type batchResult struct {
+	Action      string             `json:"action"`
+	Transaction *store.Transaction `json:"transaction"`
+}
+
+func (s *server) handleBatchTransactions(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Operations []*batchOperationInput `json:"operations" validate:"required,min=1,max=100"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	errs := make(map[string]string)
+
+	for i, op := range input.Operations {
+		for key, message := range request.Validate(op) {
+			errs[fmt.Sprintf("operations[%d].%s", i, key)] = message
+		}
+	}
+
+	if len(errs) > 0 {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	members := make(map[string]*store.Member)
+
+	// authorize caches the membership of the user for each account and permission.
+	authorize := func(accountID int64, permission string) (*store.Member, error) {
+		key := fmt.Sprintf("%d:%s", accountID, permission)
+		if member, ok := members[key]; ok {
+			return member, nil
+		}
+
+		member, err := s.authorizeAccount(r, accountID, permission)
+		if err != nil {
+			return nil, err
+		}
+
+		members[key] = member
+
+		return member, nil
+	}
+
+	operations := make([]*store.BatchOperation, 0, len(input.Operations))
+	seen := make(map[int64]bool)
+
+	for i, op := range input.Operations {
+		operation := &store.BatchOperation{Action: op.Action}
+
+		if op.Action == store.BatchCreate {
+			member, err := authorize(op.AccountID, store.PermissionCreateTransaction)
+			if err != nil {
+				s.batchErrorResponse(w, r, i, "accountID", err)
+				return
+			}
+
+			operation.Transaction = &store.Transaction{
+				UserID:    member.ID,
+				AccountID: op.AccountID,
+			}
+			op.apply(operation.Transaction)
+
+			operations = append(operations, operation)
+			continue
+		}
+
+		// Every version of a transaction is checked against the database before
+		// the batch starts, so a transaction can only be changed once in a batch.
+		if seen[op.ID] {
+			response.FailedValidationResponse(w, r, map[string]string{
+				fmt.Sprintf("operations[%d].id", i): "must not be repeated in the batch",
+			})
+			return
+		}
+		seen[op.ID] = true
+
+		old, err := s.models.Transactions.Get(op.ID)
+		if err != nil {
+			s.batchErrorResponse(w, r, i, "id", err)
+			return
+		}
+
+		member, err := authorize(old.AccountID, store.PermissionReadAccount)
+		if err != nil {
+			s.batchErrorResponse(w, r, i, "id", err)
+			return
+		}
+
+		if !member.CanEditTransaction(old) {
+			response.NotPermittedResponse(w, r)
+			return
+		}
+
+		// The embedded records are not part of the batch results.
+		old.User = nil
+		old.Account = nil
+
+		operation.Old = old
+
+		if op.Action == store.BatchUpdate {
+			ts := *old
+			op.apply(&ts)
+			operation.Transaction = &ts
+		} else {
+			operation.Transaction = old
+		}
+
+		operations = append(operations, operation)
+	}
+
+	if err := s.models.BatchTX(operations, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrEditConflict) {
+			response.EditConflictResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	results := make([]*batchResult, len(operations))
+
+	for i, operation := range operations {
+		results[i] = &batchResult{Action: operation.Action, Transaction: operation.Transaction}
+	}
+
+	s.background(func() {
+		for _, operation := range operations {
+			if operation.Action == store.BatchDelete {
+				continue
+			}
+
+			account, err := s.models.Accounts.Get(operation.Transaction.AccountID)
+			if err == nil {
+				err = s.detectAnomalies(operation.Transaction, account)
+			}
+
+			if err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"request_method": r.Method,
+					"request_url":    r.URL.String(),
+					"client_ip":      clientIP(r),
+				}).WithError(err).Error("background anomaly detection error")
+			}
+		}
+	})
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"results": results}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// batchErrorResponse reports the records which are not found or not
+// accessible as a validation error of the field of the operation.
+func (s *server) batchErrorResponse(w http.ResponseWriter, r *http.Request, index int, field string, err error) {
+	key := fmt.Sprintf("operations[%d].%s", index, field)
+
+	switch {
+	case errors.Is(err, store.ErrRecordNotFound):
+		response.FailedValidationResponse(w, r, map[string]string{key: "does not exist"})
+	case errors.Is(err, errNotPermitted):
+		response.NotPermittedResponse(w, r)
+	default:
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
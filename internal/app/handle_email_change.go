@@ -0,0 +1,181 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const (
+	emailChangeConfirmDuration = 24 * time.Hour
+	// emailChangeCancelDuration is longer so that the owner of the old email
+	// can take the account back after a hijacker confirms the change.
+	emailChangeCancelDuration = 7 * 24 * time.Hour
+)
+
+// requestEmailChange sends the confirm token to the new email and
+// a notification with the cancel token to the old email.
+func (s *server) requestEmailChange(r *http.Request, change *store.EmailChange) error {
+	confirmToken, cancelToken, err := s.models.RequestEmailChangeTX(change, emailChangeConfirmDuration, emailChangeCancelDuration)
+	if err != nil {
+		return err
+	}
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"confirmToken": confirmToken.Plaintext,
+		}
+
+		if err := s.mailer.Send(change.NewEmail, "change_mail.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"newEmail":    change.NewEmail,
+			"cancelToken": cancelToken.Plaintext,
+		}
+
+		if err := s.mailer.Send(change.OldEmail, "change_mail_requested.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+
+	return nil
+}
+
+func (s *server) handleConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlainText string `json:"token" validate:"required,max=26"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	ipKey := ipAttemptKey("email-change", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
+	user, _, err := s.models.ConfirmEmailChangeTX(input.TokenPlainText)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRecordNotFound):
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
+			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired email change token"})
+		case errors.Is(err, store.ErrDuplicateEmail):
+			response.FailedValidationResponse(w, r, map[string]string{"email": "is already exist"})
+		case errors.Is(err, store.ErrEditConflict):
+			response.EditConflictResponse(w, r)
+		default:
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	s.background(func() {
+		if err := s.cache.User.Set(user); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background cache error")
+		}
+	})
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"user": user}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// handleCancelEmailChange cancels the change from the old email. If the change is already
+// confirmed, the old email is restored and all the sessions and the API tokens are revoked
+// since the account may be taken over.
+func (s *server) handleCancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlainText string `json:"token" validate:"required,max=26"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	ipKey := ipAttemptKey("email-change", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
+	user, change, err := s.models.CancelEmailChangeTX(input.TokenPlainText)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRecordNotFound):
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
+			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired email change token"})
+		case errors.Is(err, store.ErrDuplicateEmail):
+			response.FailedValidationResponse(w, r, map[string]string{"email": "the old email is used by another account"})
+		case errors.Is(err, store.ErrEditConflict):
+			response.EditConflictResponse(w, r)
+		default:
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	message := "the email change was successfully cancelled"
+
+	if change.IsConfirmed() {
+		if _, err := s.revokeSessions(user.ID, 0); err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+
+		if err := s.models.APITokens.RevokeAllForUser(user.ID); err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+
+		s.background(func() {
+			if err := s.cache.User.Set(user); err != nil {
+				s.logger.WithFields(map[string]interface{}{
+					"request_method": r.Method,
+					"request_url":    r.URL.String(),
+					"client_ip":      clientIP(r),
+				}).WithError(err).Error("background cache error")
+			}
+		})
+
+		message = "your email was restored, all the sessions were logged out and the API tokens were revoked, please reset your password"
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"message": message}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
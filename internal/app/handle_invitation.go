@@ -0,0 +1,208 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+// inviteUser sends an invitation to an email which does not belong to a user yet.
+func (s *server) inviteUser(w http.ResponseWriter, r *http.Request, inviter *store.Member, accountID int64, email string, role string) {
+	account, err := s.models.Accounts.Get(accountID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	invitation, err := s.models.Invitations.New(inviter.ID, account.ID, email, role, 7*24*time.Hour)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"invitationToken": invitation.Plaintext,
+			"inviterName":     inviter.Name,
+			"accountTitle":    account.Title,
+		}
+
+		if err := s.mailer.Send(email, "account_invitation.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+
+	env := response.Envelope{"message": "an invitation will be sent to the email"}
+
+	if err := response.JSON(w, http.StatusAccepted, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleListInvitations(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, id, store.PermissionManageMembers); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	invitations, err := s.models.Invitations.GetAllByAccountID(id)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"invitations": invitations}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, id, store.PermissionManageMembers); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if err := s.models.Invitations.Delete(id, vars["email"]); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "invitation successfully revoked"})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlainText string `json:"token" validate:"required,max=26"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	invitation, err := s.models.Invitations.GetByToken(input.TokenPlainText)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired invitation token"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	if err := s.models.AcceptInvitationTX(invitation, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "you are added to the account"})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleDeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlainText string `json:"token" validate:"required,max=26"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	invitation, err := s.models.Invitations.GetByToken(input.TokenPlainText)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired invitation token"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := s.models.Invitations.Delete(invitation.AccountID, invitation.Email); err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	err = response.JSON(w, http.StatusOK, response.Envelope{"message": "invitation successfully declined"})
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// acceptPendingInvitations adds the user to the accounts the email of the user was
+// invited to before the user was activated. The invitations of the deleted accounts are skipped.
+func (s *server) acceptPendingInvitations(r *http.Request, user *store.User) error {
+	invitations, err := s.models.Invitations.GetAllByEmail(user.Email)
+	if err != nil {
+		return err
+	}
+
+	for _, invitation := range invitations {
+		err := s.models.AcceptInvitationTX(invitation, store.Actor{UserID: user.ID, IP: clientIP(r)})
+		if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
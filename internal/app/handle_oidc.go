@@ -0,0 +1,370 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/oidc"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const (
+	oidcRequestTimeout = 15 * time.Second
+
+	// oidcStateCookie binds the state of a flow to the browser which started it, so that
+	// a code and a state of a flow cannot be used to log another browser in.
+	oidcStateCookie = "oidc_state"
+)
+
+// handleStartOIDCLogin returns the URL of the provider the user is sent to for logging in.
+func (s *server) handleStartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	s.startOIDCFlow(w, r, 0)
+}
+
+// handleLinkIdentity returns the URL of the provider the user is sent to for linking the identity.
+func (s *server) handleLinkIdentity(w http.ResponseWriter, r *http.Request) {
+	s.startOIDCFlow(w, r, s.contextGetUser(r).ID)
+}
+
+func (s *server) startOIDCFlow(w http.ResponseWriter, r *http.Request, userID int64) {
+	provider, ok := s.providers[mux.Vars(r)["provider"]]
+	if !ok {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	nonce, err := oidc.NewState()
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oidcRequestTimeout)
+	defer cancel()
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	flow := &cache.OIDCState{
+		Provider: provider.Name(),
+		Verifier: verifier,
+		Nonce:    nonce,
+		UserID:   userID,
+	}
+
+	if err := s.cache.OIDC.Add(state, flow); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	http.SetCookie(w, s.oidcStateCookie(state, int(cache.OIDCStateTTL.Seconds())))
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"authorizationURL": authURL}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// handleOIDCLogin logs the user in with the identity. The identity is linked to the user
+// with its verified email or to a new user if no user has the email.
+func (s *server) handleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	claims, ok := s.exchangeOIDCCode(w, r, 0)
+	if !ok {
+		return
+	}
+
+	provider := mux.Vars(r)["provider"]
+
+	identity, err := s.models.Identities.GetBySubject(provider, claims.Subject)
+	switch {
+	case err == nil:
+		user, err := s.models.Users.Get(identity.UserID)
+		if err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+
+		s.login(w, r, user)
+		return
+	case !errors.Is(err, store.ErrRecordNotFound):
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		response.FailedValidationResponse(w, r, map[string]string{"email": "must be verified by the provider"})
+		return
+	}
+
+	identity = &store.Identity{Provider: provider, Subject: claims.Subject, Email: claims.Email}
+
+	user, err := s.models.Users.GetByEmail(claims.Email)
+	switch {
+	case err == nil:
+		err = s.linkIdentityByEmail(r, user, identity)
+	case errors.Is(err, store.ErrRecordNotFound):
+		user, err = s.createUserWithIdentity(r, claims, identity)
+	}
+
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrProviderLinked):
+			response.FailedValidationResponse(w, r, map[string]string{"email": "is already linked to another account of the provider"})
+		case errors.Is(err, store.ErrDuplicateIdentity), errors.Is(err, store.ErrDuplicateEmail), errors.Is(err, store.ErrEditConflict):
+			// A concurrent login with the same identity has won the race.
+			response.EditConflictResponse(w, r)
+		default:
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	s.login(w, r, user)
+}
+
+// linkIdentityByEmail links the identity to the user with the same email. A user who has
+// not activated the account may not own the email, so the password is replaced and the
+// sessions and the API tokens are revoked before the account is activated by the verified email.
+func (s *server) linkIdentityByEmail(r *http.Request, user *store.User, identity *store.Identity) error {
+	if user.IsActivated {
+		identity.UserID = user.ID
+		return s.models.Identities.Insert(identity)
+	}
+
+	password, err := oidc.NewState()
+	if err != nil {
+		return err
+	}
+
+	if err := user.Password.Set(password); err != nil {
+		return err
+	}
+
+	user.IsActivated = true
+
+	if err := s.models.LinkIdentityTX(user, identity); err != nil {
+		return err
+	}
+
+	if _, err := s.revokeSessions(user.ID, 0); err != nil {
+		return err
+	}
+
+	if err := s.models.APITokens.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+
+	s.background(func() {
+		if err := s.cache.User.Set(user); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background cache error")
+		}
+	})
+
+	return nil
+}
+
+// createUserWithIdentity creates an activated user with a random password. The user
+// can log in with the provider or set a password with a password reset. The email is
+// verified by the provider, so the pending invitations of the email are accepted.
+func (s *server) createUserWithIdentity(r *http.Request, claims *oidc.Claims, identity *store.Identity) (*store.User, error) {
+	name := claims.Name
+	if name == "" {
+		name = claims.Email
+	}
+
+	user := &store.User{
+		Name:        name,
+		Email:       claims.Email,
+		IsActivated: true,
+	}
+
+	password, err := oidc.NewState()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := user.Password.Set(password); err != nil {
+		return nil, err
+	}
+
+	if err := s.models.CreateUserWithIdentityTX(user, identity); err != nil {
+		return nil, err
+	}
+
+	if err := s.acceptPendingInvitations(r, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// handleCompleteLinkIdentity links the identity to the user who started the flow.
+func (s *server) handleCompleteLinkIdentity(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+
+	claims, ok := s.exchangeOIDCCode(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	identity := &store.Identity{
+		UserID:   user.ID,
+		Provider: mux.Vars(r)["provider"],
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+
+	if err := s.models.Identities.Insert(identity); err != nil {
+		switch {
+		case errors.Is(err, store.ErrDuplicateIdentity):
+			response.FailedValidationResponse(w, r, map[string]string{"provider": "is linked to another user"})
+		case errors.Is(err, store.ErrProviderLinked):
+			response.FailedValidationResponse(w, r, map[string]string{"provider": "is already linked"})
+		default:
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := response.JSON(w, http.StatusCreated, response.Envelope{"identity": identity}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// oidcStateCookie returns the cookie of the state which is only sent back to the OIDC routes.
+func (s *server) oidcStateCookie(state string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/v1/users",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   s.config.Env != "development",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// exchangeOIDCCode exchanges the code of the flow for the claims of the user on the provider.
+// The flow must be started by the user, or not by any user for logging in, so that a flow of
+// a user cannot be used to link the identity of another user.
+func (s *server) exchangeOIDCCode(w http.ResponseWriter, r *http.Request, userID int64) (*oidc.Claims, bool) {
+	var input struct {
+		Code  string `json:"code" validate:"required"`
+		State string `json:"state" validate:"required"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return nil, false
+	}
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return nil, false
+	}
+
+	provider, ok := s.providers[mux.Vars(r)["provider"]]
+	if !ok {
+		response.NotFoundResponse(w, r)
+		return nil, false
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(input.State)) != 1 {
+		response.FailedValidationResponse(w, r, map[string]string{"state": "invalid or expired state"})
+		return nil, false
+	}
+
+	http.SetCookie(w, s.oidcStateCookie("", -1))
+
+	flow, err := s.cache.OIDC.Pop(input.State)
+	if err != nil && !errors.Is(err, cache.ErrRecordNotFound) {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return nil, false
+	}
+
+	if flow == nil || flow.Provider != provider.Name() || flow.UserID != userID {
+		response.FailedValidationResponse(w, r, map[string]string{"state": "invalid or expired state"})
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oidcRequestTimeout)
+	defer cancel()
+
+	claims, err := provider.Exchange(ctx, input.Code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrExchange) || errors.Is(err, oidc.ErrInvalidIDToken) {
+			response.FailedValidationResponse(w, r, map[string]string{"code": "invalid or expired authorization code"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return nil, false
+	}
+
+	return claims, true
+}
+
+func (s *server) handleListIdentities(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+
+	identities, err := s.models.Identities.GetAllByUserID(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"identities": identities}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleUnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	if err := s.models.Identities.Delete(id, user.ID); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	env := response.Envelope{"message": "the identity is successfully unlinked"}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
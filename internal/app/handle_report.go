@@ -0,0 +1,217 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+type netWorthAccount struct {
+	AccountID        int64   `json:"accountID"`
+	Title            string  `json:"title"`
+	Currency         string  `json:"currency"`
+	Balance          float64 `json:"balance"`
+	ConvertedBalance float64 `json:"convertedBalance"`
+}
+
+type netWorthPoint struct {
+	Date  time.Time `json:"date"`
+	Total float64   `json:"total"`
+}
+
+type cashFlowMonth struct {
+	Month   time.Time `json:"month"`
+	Inflow  float64   `json:"inflow"`
+	Outflow float64   `json:"outflow"`
+	Net     float64   `json:"net"`
+}
+
+func (s *server) handleGetNetWorth(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Months int `json:"months" validate:"gt=0,lt=121"`
+	}
+
+	qs := r.URL.Query()
+
+	input.Months = request.ReadInt(qs, "months", 12)
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	accounts, err := s.models.Users.GetAccounts(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	rates, err := s.models.Rates.GetAll()
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	firstMonth := currentMonth.AddDate(0, -(input.Months - 1), 0)
+
+	// Future-dated transactions are already part of the account totals,
+	// so the flows after the current month are needed as well.
+	flows, err := s.models.Reports.GetMonthlyFlows(user.ID, firstMonth, now.AddDate(100, 0, 0))
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	var total float64
+	items := make([]netWorthAccount, 0, len(accounts))
+
+	for _, account := range accounts {
+		balance := account.TotalIncome - account.TotalExpense
+
+		converted, err := rates.Convert(balance, account.Currency, user.Currency)
+		if err != nil {
+			s.conversionErrorResponse(w, r, err)
+			return
+		}
+
+		total += converted
+
+		items = append(items, netWorthAccount{
+			AccountID:        account.ID,
+			Title:            account.Title,
+			Currency:         account.Currency,
+			Balance:          balance,
+			ConvertedBalance: converted,
+		})
+	}
+
+	netByMonth := make(map[string]float64)
+	var future float64
+
+	for _, flow := range flows {
+		net, err := rates.Convert(flow.Earning-flow.Spending, flow.Currency, user.Currency)
+		if err != nil {
+			s.conversionErrorResponse(w, r, err)
+			return
+		}
+
+		if flow.Month.After(currentMonth) {
+			future += net
+			continue
+		}
+
+		netByMonth[flow.Month.Format("2006-01")] += net
+	}
+
+	history := make([]netWorthPoint, input.Months)
+	value := total - future
+
+	for i := 0; i < input.Months; i++ {
+		month := currentMonth.AddDate(0, -i, 0)
+
+		history[input.Months-1-i] = netWorthPoint{
+			Date:  month.AddDate(0, 1, -1),
+			Total: value,
+		}
+
+		value -= netByMonth[month.Format("2006-01")]
+	}
+
+	env := response.Envelope{"netWorth": map[string]interface{}{
+		"currency": user.Currency,
+		"total":    total,
+		"accounts": items,
+		"history":  history,
+	}}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleGetCashFlow(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+
+	before := request.ReadTime(qs, "before", time.Now())
+	after := request.ReadTime(qs, "after", time.Now().AddDate(-1, 0, 0))
+
+	if !after.Before(before) {
+		response.FailedValidationResponse(w, r, map[string]string{"after": "must be before the before parameter"})
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	rates, err := s.models.Rates.GetAll()
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	// The transfers between the accounts are neither an inflow nor an outflow.
+	flows, err := s.models.Reports.GetMonthlyCashFlows(user.ID, after, before)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	months := []*cashFlowMonth{}
+	var totalInflow, totalOutflow float64
+
+	for _, flow := range flows {
+		inflow, err := rates.Convert(flow.Earning, flow.Currency, user.Currency)
+		if err != nil {
+			s.conversionErrorResponse(w, r, err)
+			return
+		}
+
+		outflow, err := rates.Convert(flow.Spending, flow.Currency, user.Currency)
+		if err != nil {
+			s.conversionErrorResponse(w, r, err)
+			return
+		}
+
+		// The flows are ordered by month, so the accounts of the same
+		// month follow each other.
+		if len(months) == 0 || !months[len(months)-1].Month.Equal(flow.Month) {
+			months = append(months, &cashFlowMonth{Month: flow.Month})
+		}
+
+		month := months[len(months)-1]
+		month.Inflow += inflow
+		month.Outflow += outflow
+		month.Net = month.Inflow - month.Outflow
+
+		totalInflow += inflow
+		totalOutflow += outflow
+	}
+
+	env := response.Envelope{"cashFlow": map[string]interface{}{
+		"currency": user.Currency,
+		"inflow":   totalInflow,
+		"outflow":  totalOutflow,
+		"net":      totalInflow - totalOutflow,
+		"months":   months,
+	}}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) conversionErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, store.ErrUnknownCurrency) {
+		response.FailedValidationResponse(w, r, map[string]string{"currency": err.Error()})
+		return
+	}
+
+	response.ServerErrorResponse(w, r, s.logger, err)
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+// revisionChange is a revision of a transaction with the fields
+// the editor changed while replacing it with the next version.
+type revisionChange struct {
+	Version  int             `json:"version"`
+	EditorID int64           `json:"editorID"`
+	EditedAt time.Time       `json:"editedAt"`
+	Revision *store.Revision `json:"revision"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+}
+
+func (s *server) handleListRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	ts, err := s.models.Transactions.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if _, err := s.authorizeAccount(r, ts.AccountID, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	revisions, err := s.models.Revisions.GetAllByTransactionID(ts.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	// Every revision is compared with the version which replaced it,
+	// and the last one with the current version of the transaction.
+	current := store.NewRevision(ts, 0)
+	changes := make([]*revisionChange, len(revisions))
+
+	for i, revision := range revisions {
+		next := current
+		if i+1 < len(revisions) {
+			next = revisions[i+1]
+		}
+
+		before, after, err := revision.Diff(next)
+		if err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+
+		// The changes are listed from the newest to the oldest.
+		changes[len(revisions)-1-i] = &revisionChange{
+			Version:  revision.Version,
+			EditorID: revision.EditorID,
+			EditedAt: revision.CreatedAt,
+			Revision: revision,
+			Before:   before,
+			After:    after,
+		}
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"revisions": changes}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRevertTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	version, err := strconv.Atoi(vars["version"])
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	oldTS, err := s.models.Transactions.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	member, err := s.authorizeAccount(r, oldTS.AccountID, store.PermissionReadAccount)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if !member.CanEditTransaction(oldTS) {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	revision, err := s.models.Revisions.Get(oldTS.ID, version)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	newTS := *oldTS
+	revision.Apply(&newTS)
+
+	stat, err := s.models.Statistics.GetByDate(oldTS.AccountID, oldTS.Payday)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	account, err := s.models.Accounts.Get(oldTS.AccountID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := s.models.UpdateTransactionTX(&newTS, *oldTS, account, stat, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrEditConflict) {
+			response.EditConflictResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	s.background(func() {
+		if err := s.detectAnomalies(&newTS, account); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background anomaly detection error")
+		}
+	})
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"transaction": newTS}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
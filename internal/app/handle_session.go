@@ -0,0 +1,196 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const (
+	sessionTouchInterval = time.Minute
+	maxDeviceLength      = 255
+)
+
+type sessionResponse struct {
+	*store.Session
+	Current bool `json:"current"`
+}
+
+func (s *server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+	currentID := s.contextGetSessionID(r)
+
+	sessions, err := s.models.Sessions.GetAllActiveByUserID(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	results := make([]*sessionResponse, len(sessions))
+
+	for i, session := range sessions {
+		results[i] = &sessionResponse{Session: session, Current: session.ID == currentID}
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"sessions": results}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	session, err := s.models.Sessions.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if session.UserID != user.ID || session.IsRevoked() {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if err := s.revokeSession(session); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"message": "the session is successfully revoked"}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// handleRevokeOtherSessions revokes every session of the user except the current one.
+func (s *server) handleRevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+
+	revoked, err := s.revokeSessions(user.ID, s.contextGetSessionID(r))
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"message": "the other sessions are successfully revoked", "revoked": revoked}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// isSessionRevoked looks the session up in the cache first so that
+// the database is not queried for every authenticated request.
+func (s *server) isSessionRevoked(r *http.Request, id int64) (bool, error) {
+	// The tokens without a session can not be revoked, so they are not accepted.
+	if id == 0 {
+		return true, nil
+	}
+
+	revoked, err := s.cache.Session.IsRevoked(id)
+	if err == nil {
+		return revoked, nil
+	}
+	if !errors.Is(err, cache.ErrRecordNotFound) {
+		return false, err
+	}
+
+	session, err := s.models.Sessions.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	s.background(func() {
+		if err := s.cache.Session.Add(session.ID, session.IsRevoked()); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background cache error")
+		}
+	})
+
+	return session.IsRevoked(), nil
+}
+
+// touchSession updates the last used time of the session at most once in the interval.
+func (s *server) touchSession(r *http.Request, id int64) {
+	s.background(func() {
+		ok, err := s.cache.Session.ShouldTouch(id, sessionTouchInterval)
+		if err == nil && ok {
+			err = s.models.Sessions.Touch(id)
+		}
+
+		if err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background session error")
+		}
+	})
+}
+
+// revokeSession revokes the session with its refresh tokens. A session
+// which is already revoked is only removed from the cache again.
+func (s *server) revokeSession(session *store.Session) error {
+	if err := s.models.RevokeSessionTX(session); err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+		return err
+	}
+
+	return s.cache.Session.Revoke(session.ID)
+}
+
+// revokeSessions revokes the sessions of the user except the given
+// one and returns the number of the revoked sessions.
+func (s *server) revokeSessions(userID int64, exceptID int64) (int, error) {
+	sessions, err := s.models.RevokeSessionsTX(userID, exceptID)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int64, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+
+	if err := s.cache.Session.Revoke(ids...); err != nil {
+		return 0, err
+	}
+
+	return len(sessions), nil
+}
+
+// deviceName returns the name of the device the request is made from.
+func deviceName(r *http.Request) string {
+	device := r.UserAgent()
+	if device == "" {
+		return "unknown"
+	}
+
+	if len(device) > maxDeviceLength {
+		device = device[:maxDeviceLength]
+	}
+
+	return device
+}
@@ -1,11 +1,14 @@
 package app
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/forecast"
+	"github.com/nebisin/goExpense/internal/store"
 	"github.com/nebisin/goExpense/pkg/request"
 	"github.com/nebisin/goExpense/pkg/response"
 )
@@ -18,37 +21,98 @@ func (s *server) handleListStatisticsByAccount(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	user := s.contextGetUser(r)
-	users, err := s.models.Accounts.GetUsers(id)
+	if _, err := s.authorizeAccount(r, id, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	qs := r.URL.Query()
+
+	before := request.ReadTime(qs, "before", time.Now())
+	after := request.ReadTime(qs, "after", time.Now().AddDate(0, -1, 0))
+
+	statistics, err := s.models.Statistics.GetAll(id, after, before)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
-	isMember := false
-	for _, value := range users {
-		if value.ID == user.ID {
-			isMember = true
-			break
-		}
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"statistics": statistics}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
 	}
-	if !isMember {
+}
+
+func (s *server) handleGetForecastByAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
 		response.NotFoundResponse(w, r)
 		return
 	}
 
+	if _, err := s.authorizeAccount(r, id, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	var input struct {
+		Until  time.Time `json:"until" validate:"required"`
+		Months int       `json:"months" validate:"gt=0,lt=25"`
+	}
+
 	qs := r.URL.Query()
 
-	before := request.ReadTime(qs, "before", time.Now())
-	after := request.ReadTime(qs, "after", time.Now().AddDate(0, -1, 0))
+	today := time.Now().UTC().Truncate(24 * time.Hour)
 
-	statistics, err := s.models.Statistics.GetAll(id, after, before)
+	input.Until = request.ReadTime(qs, "until", today.AddDate(0, 1, 0))
+	input.Months = request.ReadInt(qs, "months", 3)
+
+	if errs := request.Validate(input); errs != nil {
+		response.FailedValidationResponse(w, r, errs)
+		return
+	}
+
+	if !input.Until.After(today) || input.Until.After(today.AddDate(2, 0, 0)) {
+		response.FailedValidationResponse(w, r, map[string]string{"until": "must be a date within the next two years"})
+		return
+	}
+
+	account, err := s.models.Accounts.Get(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	historyStart := today.AddDate(0, -input.Months, 0)
+
+	history, err := s.models.Statistics.GetAll(id, historyStart, today)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
-	if err := response.JSON(w, http.StatusOK, response.Envelope{"statistics": statistics}); err != nil {
+	// The account totals already contain the future-dated transactions,
+	// so they are taken back to find the balance of today.
+	scheduled, err := s.models.Statistics.GetAll(id, today.AddDate(0, 0, 1), today.AddDate(100, 0, 0))
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	balance := account.TotalIncome - account.TotalExpense
+	for _, stat := range scheduled {
+		balance -= stat.Earning - stat.Spending
+	}
+
+	historyDays := int(today.Sub(historyStart).Hours() / 24)
+
+	result := forecast.Project(balance, history, scheduled, today, input.Until, historyDays)
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"forecast": result}); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 	}
 }
@@ -3,13 +3,189 @@ package app
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/nebisin/goExpense/internal/cache"
 	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/auth"
 	"github.com/nebisin/goExpense/pkg/request"
 	"github.com/nebisin/goExpense/pkg/response"
 )
 
+const magicLinkDuration = 15 * time.Minute
+
+// magicLinkPolicy limits how many login links are sent to an email.
+var magicLinkPolicy = cache.AttemptPolicy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Minute,
+	MaxDelay:     time.Hour,
+	Window:       time.Hour,
+}
+
+const (
+	defaultAccessTokenDuration  = 15 * time.Minute
+	defaultRefreshTokenDuration = 30 * 24 * time.Hour
+)
+
+func (s *server) accessTokenDuration() time.Duration {
+	if s.config.AccessTokenDuration <= 0 {
+		return defaultAccessTokenDuration
+	}
+	return s.config.AccessTokenDuration
+}
+
+func (s *server) refreshTokenDuration() time.Duration {
+	if s.config.RefreshTokenDuration <= 0 {
+		return defaultRefreshTokenDuration
+	}
+	return s.config.RefreshTokenDuration
+}
+
+func (s *server) createAccessToken(userID int64, sessionID int64) (string, error) {
+	return s.tokenMaker.CreateToken(userID, sessionID, s.accessTokenDuration())
+}
+
+// handleJWKS publishes the public keys so that the other services can verify the access tokens.
+// The keys are only published when the configured token type is a JWT.
+func (s *server) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	publisher, ok := s.tokenMaker.(auth.KeyPublisher)
+	if !ok {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"keys": publisher.JWKS().Keys}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	token, err := s.models.RefreshTokens.GetByToken(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.InvalidAuthenticationTokenResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	session, err := s.models.Sessions.GetByFamily(token.Family)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.InvalidAuthenticationTokenResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	refreshToken, err := s.models.RotateRefreshTokenTX(token, s.refreshTokenDuration())
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrRefreshTokenReused):
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+				"user_id":        token.UserID,
+				"session_id":     session.ID,
+			}).Warn("refresh token reuse is detected, the session is revoked")
+
+			if err := s.revokeSession(session); err != nil {
+				response.ServerErrorResponse(w, r, s.logger, err)
+				return
+			}
+
+			response.InvalidAuthenticationTokenResponse(w, r)
+		case errors.Is(err, store.ErrRecordNotFound):
+			response.InvalidAuthenticationTokenResponse(w, r)
+		default:
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	accessToken, err := s.createAccessToken(token.UserID, session.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	s.touchSession(r, session.ID)
+
+	env := response.Envelope{"authenticationToken": accessToken, "refreshToken": refreshToken}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// handleLogout revokes the session of the refresh token.
+func (s *server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	token, err := s.models.RefreshTokens.GetByToken(input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.InvalidAuthenticationTokenResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	session, err := s.models.Sessions.GetByFamily(token.Family)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.InvalidAuthenticationTokenResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := s.revokeSession(session); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"message": "you have been logged out"}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
 func (s *server) handleNewActivationToken(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Email string `json:"email" validator:"required,email"`
@@ -61,6 +237,7 @@ func (s *server) handleNewActivationToken(w http.ResponseWriter, r *http.Request
 			s.logger.WithFields(map[string]interface{}{
 				"request_method": r.Method,
 				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
 			}).WithError(err).Error("background email error")
 		}
 	})
@@ -118,6 +295,7 @@ func (s *server) handleCreatePasswordResetToken(w http.ResponseWriter, r *http.R
 			s.logger.WithFields(map[string]interface{}{
 				"request_method": r.Method,
 				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
 			}).WithError(err).Error("background email error")
 		}
 	})
@@ -128,3 +306,75 @@ func (s *server) handleCreatePasswordResetToken(w http.ResponseWriter, r *http.R
 		response.ServerErrorResponse(w, r, s.logger, err)
 	}
 }
+
+// handleCreateMagicLinkToken emails a login link to the user. The response does
+// not tell whether the email belongs to a user.
+func (s *server) handleCreateMagicLinkToken(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	emailKey := "magic-link." + strings.ToLower(input.Email)
+
+	if !s.checkAttempts(w, r, emailKey) {
+		return
+	}
+
+	s.failAttempt(r, emailKey, magicLinkPolicy)
+
+	env := response.Envelope{"message": "an email will be sent to you containing the login link if you have an account"}
+
+	user, err := s.models.Users.GetByEmail(input.Email)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			if err := response.JSON(w, http.StatusAccepted, env); err != nil {
+				response.ServerErrorResponse(w, r, s.logger, err)
+			}
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	// The links are only sent to the activated accounts like the password reset tokens.
+	if !user.IsActivated {
+		if err := response.JSON(w, http.StatusAccepted, env); err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	token, err := s.models.Tokens.New(user.ID, magicLinkDuration, store.ScopeMagicLink)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	s.background(func() {
+		data := map[string]interface{}{
+			"magicLinkToken": token.Plaintext,
+		}
+
+		if err := s.mailer.Send(user.Email, "magic_link.tmpl", data); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background email error")
+		}
+	})
+
+	if err := response.JSON(w, http.StatusAccepted, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
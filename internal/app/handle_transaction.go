@@ -33,22 +33,9 @@ func (s *server) handleCreateTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user := s.contextGetUser(r)
-	users, err := s.models.Accounts.GetUsers(input.AccountID)
+	member, err := s.authorizeAccount(r, input.AccountID, store.PermissionCreateTransaction)
 	if err != nil {
-		response.ServerErrorResponse(w, r, s.logger, err)
-		return
-	}
-
-	isMember := false
-	for _, value := range users {
-		if value.ID == user.ID {
-			isMember = true
-			break
-		}
-	}
-	if !isMember {
-		response.NotFoundResponse(w, r)
+		s.authorizationErrorResponse(w, r, err)
 		return
 	}
 
@@ -63,7 +50,7 @@ func (s *server) handleCreateTransaction(w http.ResponseWriter, r *http.Request)
 	}
 
 	ts := &store.Transaction{
-		UserID:      user.ID,
+		UserID:      member.ID,
 		AccountID:   input.AccountID,
 		Type:        input.Type,
 		Title:       input.Title,
@@ -83,13 +70,23 @@ func (s *server) handleCreateTransaction(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	if err := s.models.CreateTransactionTX(ts, account, stat); err != nil {
+	if err := s.models.CreateTransactionTX(ts, account, stat, s.contextGetActor(r)); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
 	ts.Account = account
-	ts.User = user
+	ts.User = member.User
+
+	s.background(func() {
+		if err := s.detectAnomalies(ts, account); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background anomaly detection error")
+		}
+	})
 
 	err = response.JSON(w, http.StatusCreated, response.Envelope{"transaction": ts})
 	if err != nil {
@@ -116,14 +113,18 @@ func (s *server) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := s.contextGetUser(r)
-
-	if ts.UserID != user.ID {
-		response.NotFoundResponse(w, r)
+	if _, err := s.authorizeAccount(r, ts.AccountID, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
 		return
 	}
 
-	ts.User = user
+	tag := etag("transaction", ts.ID, ts.Version)
+	w.Header().Set("ETag", tag)
+
+	if checkIfNoneMatch(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
 	if err := response.JSON(w, http.StatusOK, response.Envelope{"transaction": ts}); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
@@ -138,8 +139,6 @@ func (s *server) handleDeleteTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user := s.contextGetUser(r)
-
 	ts, err := s.models.Transactions.Get(id)
 	if err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
@@ -150,8 +149,19 @@ func (s *server) handleDeleteTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if ts.UserID != user.ID {
-		response.NotFoundResponse(w, r)
+	member, err := s.authorizeAccount(r, ts.AccountID, store.PermissionReadAccount)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if !member.CanEditTransaction(ts) {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	if !checkIfMatch(r, etag("transaction", ts.ID, ts.Version)) {
+		response.PreconditionFailedResponse(w, r)
 		return
 	}
 
@@ -167,7 +177,7 @@ func (s *server) handleDeleteTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := s.models.DeleteTransactionTX(ts, account, stat); err != nil {
+	if err := s.models.DeleteTransactionTX(ts, account, stat, s.contextGetActor(r)); err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
 			response.NotFoundResponse(w, r)
 		} else {
@@ -200,10 +210,19 @@ func (s *server) handleUpdateTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	user := s.contextGetUser(r)
+	member, err := s.authorizeAccount(r, oldTS.AccountID, store.PermissionReadAccount)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
 
-	if oldTS.UserID != user.ID {
-		response.NotFoundResponse(w, r)
+	if !member.CanEditTransaction(oldTS) {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	if !checkIfMatch(r, etag("transaction", oldTS.ID, oldTS.Version)) {
+		response.PreconditionFailedResponse(w, r)
 		return
 	}
 
@@ -264,7 +283,7 @@ func (s *server) handleUpdateTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := s.models.UpdateTransactionTX(&newTS, *oldTS, account, stat); err != nil {
+	if err := s.models.UpdateTransactionTX(&newTS, *oldTS, account, stat, s.contextGetActor(r)); err != nil {
 		if errors.Is(err, store.ErrEditConflict) {
 			response.EditConflictResponse(w, r)
 		} else {
@@ -273,6 +292,18 @@ func (s *server) handleUpdateTransaction(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	s.background(func() {
+		if err := s.detectAnomalies(&newTS, account); err != nil {
+			s.logger.WithFields(map[string]interface{}{
+				"request_method": r.Method,
+				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
+			}).WithError(err).Error("background anomaly detection error")
+		}
+	})
+
+	w.Header().Set("ETag", etag("transaction", newTS.ID, newTS.Version))
+
 	if err := response.JSON(w, http.StatusOK, response.Envelope{"transaction": newTS}); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 	}
@@ -336,23 +367,8 @@ func (s *server) handleListTransactionsByAccount(w http.ResponseWriter, r *http.
 		return
 	}
 
-	users, err := s.models.Accounts.GetUsers(id)
-	if err != nil {
-		response.ServerErrorResponse(w, r, s.logger, err)
-		return
-	}
-
-	user := s.contextGetUser(r)
-
-	isMember := false
-	for _, value := range users {
-		if value.ID == user.ID {
-			isMember = true
-			break
-		}
-	}
-	if !isMember {
-		response.NotFoundResponse(w, r)
+	if _, err := s.authorizeAccount(r, id, store.PermissionReadAccount); err != nil {
+		s.authorizationErrorResponse(w, r, err)
 		return
 	}
 
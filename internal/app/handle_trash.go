@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const defaultTrashRetention = 30 * 24 * time.Hour
+
+func (s *server) handleListTrash(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+
+	accounts, err := s.models.Accounts.GetTrash(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	transactions, err := s.models.Transactions.GetTrash(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"trash": map[string]interface{}{
+		"accounts":     accounts,
+		"transactions": transactions,
+	}}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRestoreTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	ts, err := s.models.Transactions.GetDeleted(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	member, err := s.authorizeAccount(r, ts.AccountID, store.PermissionReadAccount)
+	if err != nil {
+		s.authorizationErrorResponse(w, r, err)
+		return
+	}
+
+	if !member.CanEditTransaction(ts) {
+		response.NotPermittedResponse(w, r)
+		return
+	}
+
+	account, err := s.models.Accounts.Get(ts.AccountID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	stat, err := s.models.Statistics.GetByDate(ts.AccountID, ts.Payday)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			stat = &store.Statistic{}
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+	}
+
+	if err := s.models.RestoreTransactionTX(ts, account, stat, s.contextGetActor(r)); err != nil {
+		switch {
+		case errors.Is(err, store.ErrRecordNotFound):
+			response.NotFoundResponse(w, r)
+		case errors.Is(err, store.ErrEditConflict):
+			response.EditConflictResponse(w, r)
+		default:
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"transaction": ts}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleRestoreAccount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	account, err := s.models.Accounts.GetDeleted(id)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	// Only the owner can delete the account, so only the owner can restore it.
+	if account.OwnerID != s.contextGetUser(r).ID {
+		response.NotFoundResponse(w, r)
+		return
+	}
+
+	if err := s.models.RestoreAccountTX(account, s.contextGetActor(r)); err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.NotFoundResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"account": account}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// setupTrashPurge removes the transactions and the accounts which
+// stayed in the trash longer than the retention period.
+func (s *server) setupTrashPurge() {
+	retention := s.config.TrashRetention
+	if retention <= 0 {
+		retention = defaultTrashRetention
+	}
+
+	go func() {
+		for {
+			before := time.Now().Add(-retention)
+
+			transactions, err := s.models.Transactions.Purge(before)
+			if err != nil {
+				s.logger.WithError(err).Error("an error occurred while purging the transactions")
+			}
+
+			accounts, err := s.models.Accounts.Purge(before)
+			if err != nil {
+				s.logger.WithError(err).Error("an error occurred while purging the accounts")
+			}
+
+			if transactions > 0 || accounts > 0 {
+				s.logger.WithFields(map[string]interface{}{
+					"transactions": transactions,
+					"accounts":     accounts,
+				}).Info("purged the trash")
+			}
+
+			time.Sleep(time.Hour)
+		}
+	}()
+}
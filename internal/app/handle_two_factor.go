@@ -0,0 +1,317 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/nebisin/goExpense/pkg/request"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const (
+	totpIssuer                 = "goExpense"
+	twoFactorChallengeDuration = 5 * time.Minute
+)
+
+// handleLoginTwoFactor is the second step of the login for the users with
+// two-factor authentication. A recovery code can be used instead of the code.
+func (s *server) handleLoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ChallengeToken string `json:"challengeToken" validate:"required"`
+		Code           string `json:"code,omitempty" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+		RecoveryCode   string `json:"recoveryCode,omitempty" validate:"omitempty,max=20"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	ipKey := ipAttemptKey("two-factor", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
+	user, err := s.models.Users.GetForToken(store.ScopeTwoFactor, input.ChallengeToken)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
+			response.FailedValidationResponse(w, r, map[string]string{"challengeToken": "invalid or expired challenge token"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	accountKey := accountAttemptKey(user.Email)
+
+	if !s.checkAttempts(w, r, accountKey) {
+		return
+	}
+
+	tf, err := s.models.TwoFactors.Get(user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.InvalidCredentialsResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if !tf.Enabled {
+		response.InvalidCredentialsResponse(w, r)
+		return
+	}
+
+	if input.Code != "" {
+		err = s.useTOTP(tf, input.Code)
+	} else {
+		err = s.models.RecoveryCodes.Use(user.ID, input.RecoveryCode)
+	}
+
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.failAccountAttempt(r, user, "two-factor")
+			response.InvalidCredentialsResponse(w, r)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := s.models.Tokens.DeleteAllForUser(store.ScopeTwoFactor, user.ID); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	s.resetAttempts(r, accountKey)
+
+	s.startSession(w, r, user)
+}
+
+// handleEnrollTwoFactor creates a pending secret for the user. The two-factor
+// authentication is enabled after the user verifies a code generated from it.
+func (s *server) handleEnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user := s.contextGetUser(r)
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	tf := &store.TwoFactor{UserID: user.ID, Secret: secret}
+
+	if err := s.models.TwoFactors.SetPending(tf); err != nil {
+		if errors.Is(err, store.ErrTwoFactorEnabled) {
+			response.BadRequestResponse(w, r, err)
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	env := response.Envelope{
+		"secret": secret,
+		"uri":    auth.TOTPURI(totpIssuer, user.Email, secret),
+	}
+
+	if err := response.JSON(w, http.StatusCreated, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleActivateTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Code string `json:"code" validate:"required,len=6,numeric"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	tf, err := s.models.TwoFactors.Get(user.ID)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.BadRequestResponse(w, r, errors.New("two-factor authentication must be enrolled first"))
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if tf.Enabled {
+		response.BadRequestResponse(w, r, store.ErrTwoFactorEnabled)
+		return
+	}
+
+	step, ok, err := auth.ValidateTOTP(tf.Secret, input.Code, time.Now())
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if !ok {
+		response.FailedValidationResponse(w, r, map[string]string{"code": "invalid or expired code"})
+		return
+	}
+
+	codes, err := s.models.EnableTwoFactorTX(user.ID, step)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			response.FailedValidationResponse(w, r, map[string]string{"code": "invalid or expired code"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"recoveryCodes": codes}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+func (s *server) handleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password" validate:"required,max=72"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	if !s.confirmPassword(w, r, user.ID, input.Password) {
+		return
+	}
+
+	if err := s.models.DisableTwoFactorTX(user.ID); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"message": "two-factor authentication is successfully disabled"}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// handleRegenerateRecoveryCodes replaces the recovery codes of the user, so the old ones can't be used anymore.
+func (s *server) handleRegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Password string `json:"password" validate:"required,max=72"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	user := s.contextGetUser(r)
+
+	if !s.confirmPassword(w, r, user.ID, input.Password) {
+		return
+	}
+
+	tf, err := s.models.TwoFactors.Get(user.ID)
+	if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if tf == nil || !tf.Enabled {
+		response.BadRequestResponse(w, r, errors.New("two-factor authentication is not enabled"))
+		return
+	}
+
+	codes, err := s.models.RegenerateRecoveryCodesTX(user.ID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := response.JSON(w, http.StatusOK, response.Envelope{"recoveryCodes": codes}); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
+// useTOTP verifies the code and records its step so the same code can't be used twice.
+// It returns ErrRecordNotFound if the code is invalid or already used.
+func (s *server) useTOTP(tf *store.TwoFactor, code string) error {
+	step, ok, err := auth.ValidateTOTP(tf.Secret, code, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	return s.models.TwoFactors.UseStep(tf.UserID, step)
+}
+
+// confirmPassword checks the password of the user before a sensitive change.
+// It writes the error response and returns false if the password doesn't match.
+// The failures count against the account like the failed logins, so that a stolen
+// session cannot be used to guess the password.
+func (s *server) confirmPassword(w http.ResponseWriter, r *http.Request, userID int64, password string) bool {
+	user, err := s.models.Users.Get(userID)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return false
+	}
+
+	ipKey := ipAttemptKey("confirm-password", r)
+	accountKey := accountAttemptKey(user.Email)
+
+	if !s.checkAttempts(w, r, ipKey, accountKey) {
+		return false
+	}
+
+	match, err := user.Password.Matches(password)
+	if err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return false
+	}
+
+	if !match {
+		s.failAccountAttempt(r, user, "confirm-password")
+		response.InvalidCredentialsResponse(w, r)
+		return false
+	}
+
+	s.resetAttempts(r, accountKey)
+
+	return true
+}
@@ -6,7 +6,6 @@ import (
 	"time"
 
 	"github.com/nebisin/goExpense/internal/store"
-	"github.com/nebisin/goExpense/pkg/auth"
 	"github.com/nebisin/goExpense/pkg/request"
 	"github.com/nebisin/goExpense/pkg/response"
 )
@@ -15,7 +14,8 @@ func (s *server) handleRegisterUser(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Name     string `json:"name" validate:"required,max=500"`
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,max=72,min=8"`
+		Password string `json:"password" validate:"required,max=72"`
+		Currency string `json:"currency,omitempty" validate:"omitempty,iso4217"`
 	}
 
 	if err := request.ReadJSON(w, r, &input); err != nil {
@@ -28,9 +28,14 @@ func (s *server) handleRegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.validatePassword(w, r, input.Password, input.Name, input.Email) {
+		return
+	}
+
 	user := &store.User{
 		Name:        input.Name,
 		Email:       input.Email,
+		Currency:    input.Currency,
 		IsActivated: false,
 	}
 
@@ -66,6 +71,7 @@ func (s *server) handleRegisterUser(w http.ResponseWriter, r *http.Request) {
 			s.logger.WithFields(map[string]interface{}{
 				"request_method": r.Method,
 				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
 			}).WithError(err).Error("background email error")
 		}
 	})
@@ -93,9 +99,20 @@ func (s *server) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ipKey := ipAttemptKey("login", r)
+	accountKey := accountAttemptKey(input.Email)
+
+	if !s.checkAttempts(w, r, ipKey, accountKey) {
+		return
+	}
+
 	user, err := s.models.Users.GetByEmail(input.Email)
 	if err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
+			// The unknown emails are blocked like the accounts, so that the
+			// blocks do not tell which emails have an account.
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
+			s.failAttempt(r, accountKey, accountAttemptPolicy)
 			response.InvalidCredentialsResponse(w, r)
 		} else {
 			response.ServerErrorResponse(w, r, s.logger, err)
@@ -110,25 +127,117 @@ func (s *server) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !match {
+		s.failAccountAttempt(r, user, "login")
 		response.InvalidCredentialsResponse(w, r)
 		return
 	}
 
-	maker, err := auth.NewJWTMaker(s.config.JwtSecret)
+	s.resetAttempts(r, accountKey)
+
+	s.login(w, r, user)
+}
+
+// handleLoginMagicLink logs the user in with the token of a login link.
+func (s *server) handleLoginMagicLink(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlainText string `json:"token" validate:"required,max=26"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	ipKey := ipAttemptKey("magic-link", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
+	userID, err := s.models.Tokens.Use(store.ScopeMagicLink, input.TokenPlainText)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
+			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired login token"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	// The other links of the user are no longer needed.
+	if err := s.models.Tokens.DeleteAllForUser(store.ScopeMagicLink, userID); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	user, err := s.models.Users.Get(userID)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
-	token, err := maker.CreateToken(user.ID, time.Hour*24*7)
+	s.login(w, r, user)
+}
+
+// login starts a session for the user who is authenticated with the first factor. The users
+// with two-factor authentication get a challenge token to be exchanged for the tokens with
+// a code from their device.
+func (s *server) login(w http.ResponseWriter, r *http.Request, user *store.User) {
+	tf, err := s.models.TwoFactors.Get(user.ID)
+	if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if tf != nil && tf.Enabled {
+		challenge, err := s.models.Tokens.New(user.ID, twoFactorChallengeDuration, store.ScopeTwoFactor)
+		if err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+
+		env := response.Envelope{"twoFactorRequired": true, "challengeToken": challenge.Plaintext}
+
+		if err := response.JSON(w, http.StatusOK, env); err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	s.startSession(w, r, user)
+}
+
+// startSession logs the user in on the device of the request
+// and responds with the access token and the refresh token.
+func (s *server) startSession(w http.ResponseWriter, r *http.Request, user *store.User) {
+	session := &store.Session{
+		UserID: user.ID,
+		Device: deviceName(r),
+		IP:     clientIP(r),
+	}
+
+	refreshToken, err := s.models.CreateSessionTX(session, s.refreshTokenDuration())
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
 	}
 
-	err = response.JSON(w, http.StatusOK, response.Envelope{"authenticationToken": token})
+	token, err := s.createAccessToken(user.ID, session.ID)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"authenticationToken": token, "refreshToken": refreshToken}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
 	}
 }
 
@@ -136,7 +245,8 @@ func (s *server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	var input struct {
 		Name        *string `json:"name,omitempty" validate:"omitempty,min=3,max=500"`
 		Email       *string `json:"email,omitempty" validate:"omitempty,email"`
-		Password    *string `json:"password,omitempty" validate:"omitempty,max=72,min=8"`
+		Currency    *string `json:"currency,omitempty" validate:"omitempty,iso4217"`
+		Password    *string `json:"password,omitempty" validate:"omitempty,max=72"`
 		OldPassword *string `json:"oldPassword,omitempty" validate:"required_with=Password"`
 	}
 
@@ -162,11 +272,24 @@ func (s *server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		user.Name = *input.Name
 	}
 
-	isEmailChanged := false
+	if input.Currency != nil {
+		user.Currency = *input.Currency
+	}
+
+	// The email is only changed after the new email is confirmed.
+	var emailChange *store.EmailChange
 	if input.Email != nil && user.Email != *input.Email {
-		isEmailChanged = true
-		user.Email = *input.Email
-		user.IsActivated = false
+		_, err := s.models.Users.GetByEmail(*input.Email)
+		switch {
+		case err == nil:
+			response.FailedValidationResponse(w, r, map[string]string{"email": "is already exist"})
+			return
+		case !errors.Is(err, store.ErrRecordNotFound):
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+
+		emailChange = &store.EmailChange{UserID: user.ID, OldEmail: user.Email, NewEmail: *input.Email}
 	}
 
 	if input.Password != nil {
@@ -181,6 +304,15 @@ func (s *server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		personal := []string{user.Name, user.Email}
+		if emailChange != nil {
+			personal = append(personal, emailChange.NewEmail)
+		}
+
+		if !s.validatePassword(w, r, *input.Password, personal...) {
+			return
+		}
+
 		if err := user.Password.Set(*input.Password); err != nil {
 			response.ServerErrorResponse(w, r, s.logger, err)
 			return
@@ -200,25 +332,25 @@ func (s *server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if isEmailChanged {
-		token, err := s.models.Tokens.New(user.ID, 3*24*time.Hour, store.ScopeActivation)
-		if err != nil {
+	// The other devices have to log in again with the new password
+	// and the API tokens have to be created again.
+	if input.Password != nil {
+		if _, err := s.revokeSessions(user.ID, s.contextGetSessionID(r)); err != nil {
 			response.ServerErrorResponse(w, r, s.logger, err)
 			return
 		}
 
-		s.background(func() {
-			data := map[string]interface{}{
-				"activationToken": token.Plaintext,
-			}
+		if err := s.models.APITokens.RevokeAllForUser(user.ID); err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
+	}
 
-			if err := s.mailer.Send(user.Email, "change_mail.tmpl", data); err != nil {
-				s.logger.WithFields(map[string]interface{}{
-					"request_method": r.Method,
-					"request_url":    r.URL.String(),
-				}).WithError(err).Error("background email error")
-			}
-		})
+	if emailChange != nil {
+		if err := s.requestEmailChange(r, emailChange); err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
+			return
+		}
 	}
 
 	s.background(func() {
@@ -226,11 +358,17 @@ func (s *server) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 			s.logger.WithFields(map[string]interface{}{
 				"request_method": r.Method,
 				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
 			}).WithError(err).Error("background cache error")
 		}
 	})
 
-	err = response.JSON(w, http.StatusOK, response.Envelope{"user": user})
+	env := response.Envelope{"user": user}
+	if emailChange != nil {
+		env["emailChange"] = emailChange
+	}
+
+	err = response.JSON(w, http.StatusOK, env)
 	if err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
@@ -252,9 +390,16 @@ func (s *server) handleActivateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ipKey := ipAttemptKey("activation", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
 	user, err := s.models.Users.GetForToken(store.ScopeActivation, input.TokenPlainText)
 	if err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
 			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired activation token"})
 		} else {
 			response.ServerErrorResponse(w, r, s.logger, err)
@@ -278,11 +423,19 @@ func (s *server) handleActivateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The accounts the email was invited to before the sign up are joined
+	// now that the user has proven the ownership of the email.
+	if err := s.acceptPendingInvitations(r, user); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
 	s.background(func() {
 		if err := s.cache.User.Set(user); err != nil {
 			s.logger.WithFields(map[string]interface{}{
 				"request_method": r.Method,
 				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
 			}).WithError(err).Error("background cache error")
 		}
 	})
@@ -294,7 +447,7 @@ func (s *server) handleActivateUser(w http.ResponseWriter, r *http.Request) {
 
 func (s *server) handlePasswordReset(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Password       string `json:"password" validate:"required,max=72,min=8"`
+		Password       string `json:"password" validate:"required,max=72"`
 		TokenPlainText string `json:"token" validator:"required,max=26"`
 	}
 
@@ -308,9 +461,16 @@ func (s *server) handlePasswordReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ipKey := ipAttemptKey("password-reset", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
 	user, err := s.models.Users.GetForToken(store.ScopePasswordReset, input.TokenPlainText)
 	if err != nil {
 		if errors.Is(err, store.ErrRecordNotFound) {
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
 			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired password reset token"})
 		} else {
 			response.ServerErrorResponse(w, r, s.logger, err)
@@ -318,6 +478,10 @@ func (s *server) handlePasswordReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !s.validatePassword(w, r, input.Password, user.Name, user.Email) {
+		return
+	}
+
 	if err := user.Password.Set(input.Password); err != nil {
 		response.ServerErrorResponse(w, r, s.logger, err)
 		return
@@ -337,11 +501,22 @@ func (s *server) handlePasswordReset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, err := s.revokeSessions(user.ID, 0); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := s.models.APITokens.RevokeAllForUser(user.ID); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
 	s.background(func() {
 		if err := s.cache.User.Set(user); err != nil {
 			s.logger.WithFields(map[string]interface{}{
 				"request_method": r.Method,
 				"request_url":    r.URL.String(),
+				"client_ip":      clientIP(r),
 			}).WithError(err).Error("background cache error")
 		}
 	})
@@ -354,6 +529,57 @@ func (s *server) handlePasswordReset(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleUnlockUser unlocks the account which is locked out after the failed
+// attempts with the token which is sent to the user when it is locked.
+func (s *server) handleUnlockUser(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		TokenPlainText string `json:"token" validate:"required,max=26"`
+	}
+
+	if err := request.ReadJSON(w, r, &input); err != nil {
+		response.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := request.Validate(input); err != nil {
+		response.FailedValidationResponse(w, r, err)
+		return
+	}
+
+	ipKey := ipAttemptKey("unlock", r)
+
+	if !s.checkAttempts(w, r, ipKey) {
+		return
+	}
+
+	user, err := s.models.Users.GetForToken(store.ScopeUnlock, input.TokenPlainText)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.failAttempt(r, ipKey, ipAttemptPolicy)
+			response.FailedValidationResponse(w, r, map[string]string{"token": "invalid or expired unlock token"})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return
+	}
+
+	if err := s.cache.Attempts.Reset(accountAttemptKey(user.Email)); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	if err := s.models.Tokens.DeleteAllForUser(store.ScopeUnlock, user.ID); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+		return
+	}
+
+	env := response.Envelope{"message": "your account was successfully unlocked"}
+
+	if err := response.JSON(w, http.StatusOK, env); err != nil {
+		response.ServerErrorResponse(w, r, s.logger, err)
+	}
+}
+
 func (s *server) handleGetMe(w http.ResponseWriter, r *http.Request) {
 	user := s.contextGetUser(r)
 
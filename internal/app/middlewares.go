@@ -1,18 +1,18 @@
 package app
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
-	"net"
+	"io"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/nebisin/goExpense/internal/cache"
 	"github.com/nebisin/goExpense/internal/store"
-	"github.com/nebisin/goExpense/pkg/auth"
 	"github.com/nebisin/goExpense/pkg/response"
-	"golang.org/x/time/rate"
 )
 
 func (s *server) authenticate(next http.Handler) http.Handler {
@@ -34,19 +34,50 @@ func (s *server) authenticate(next http.Handler) http.Handler {
 
 		token := headerParts[1]
 
-		maker, err := auth.NewJWTMaker(s.config.JwtSecret)
-		if err != nil {
-			response.ServerErrorResponse(w, r, s.logger, err)
-			return
-		}
+		var userID int64
 
-		payload, err := maker.VerifyToken(token)
-		if err != nil {
-			response.InvalidAuthenticationTokenResponse(w, r)
-			return
+		if strings.HasPrefix(token, store.APITokenPrefix) {
+			apiToken, err := s.models.APITokens.GetByToken(token)
+			if err != nil {
+				if errors.Is(err, store.ErrRecordNotFound) {
+					response.InvalidAuthenticationTokenResponse(w, r)
+				} else {
+					response.ServerErrorResponse(w, r, s.logger, err)
+				}
+				return
+			}
+
+			s.touchAPIToken(r, apiToken)
+
+			r = s.contextSetAPIToken(r, apiToken)
+
+			userID = apiToken.UserID
+		} else {
+			payload, err := s.tokenMaker.VerifyToken(token)
+			if err != nil {
+				response.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			revoked, err := s.isSessionRevoked(r, payload.SessionID)
+			if err != nil {
+				response.ServerErrorResponse(w, r, s.logger, err)
+				return
+			}
+
+			if revoked {
+				response.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			s.touchSession(r, payload.SessionID)
+
+			r = s.contextSetSessionID(r, payload.SessionID)
+
+			userID = payload.UserID
 		}
 
-		user, err := s.cache.User.Get(payload.UserID)
+		user, err := s.cache.User.Get(userID)
 		if err != nil && err != cache.ErrRecordNotFound {
 			response.ServerErrorResponse(w, r, s.logger, err)
 			return
@@ -57,7 +88,7 @@ func (s *server) authenticate(next http.Handler) http.Handler {
 			return
 		}
 
-		user, err = s.models.Users.Get(payload.UserID)
+		user, err = s.models.Users.Get(userID)
 		if err != nil {
 			if errors.Is(err, store.ErrRecordNotFound) {
 				response.InvalidAuthenticationTokenResponse(w, r)
@@ -72,6 +103,7 @@ func (s *server) authenticate(next http.Handler) http.Handler {
 				s.logger.WithFields(map[string]interface{}{
 					"request_method": r.Method,
 					"request_url":    r.URL.String(),
+					"client_ip":      clientIP(r),
 				}).WithError(err).Error("background cache error")
 			}
 		})
@@ -82,6 +114,8 @@ func (s *server) authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// requireAuthenticatedUser does not accept the API tokens, since they
+// can only be used for the routes which require a scope.
 func (s *server) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := s.contextGetUser(r)
@@ -91,6 +125,43 @@ func (s *server) requireAuthenticatedUser(next http.HandlerFunc) http.HandlerFun
 			return
 		}
 
+		if s.contextGetAPIToken(r) != nil {
+			response.NotPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireScope accepts the API tokens with the scope in addition to the users who logged in.
+func (s *server) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.contextGetUser(r)
+
+		if user.IsAnonymous() {
+			response.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		if apiToken := s.contextGetAPIToken(r); apiToken != nil && !apiToken.HasScope(scope) {
+			response.NotPermittedResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAllAccounts rejects the API tokens which are restricted to some of the accounts
+// for the routes which are not bound to an account and would expose the other ones.
+func (s *server) requireAllAccounts(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiToken := s.contextGetAPIToken(r); apiToken != nil && apiToken.IsRestricted() {
+			response.NotPermittedResponse(w, r)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -121,10 +192,13 @@ func (s *server) enableCORS(next http.Handler) http.Handler {
 			for i := range s.config.CORS.TrustedOrigins {
 				if origin == s.config.CORS.TrustedOrigins[i] {
 					w.Header().Set("Access-Control-Allow-Origin", origin)
+					// The state cookie of the OIDC flows is sent by the trusted origins.
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+					w.Header().Set("Access-Control-Expose-Headers", "ETag, Retry-After, "+rateLimitHeaders)
 
 					if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
 						w.Header().Set("Access-Control-Allow-Methods", "OPTIONS, PUT, PATCH, DELETE")
-						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+						w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Idempotency-Key, If-Match, If-None-Match")
 
 						w.WriteHeader(http.StatusOK)
 						return
@@ -142,43 +216,124 @@ func (s *server) enableCORS(next http.Handler) http.Handler {
 	})
 }
 
-func (s *server) rateLimit(next http.Handler) http.Handler {
+func (s *server) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			response.ServerErrorResponse(w, r, s.logger, err)
+		defer func() {
+			if err := recover(); err != nil {
+				w.Header().Set("Connection", "close")
+				response.ServerErrorResponse(w, r, s.logger, fmt.Errorf("%s", err))
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// idempotencyRecorder passes the response to the client
+// and keeps a copy of it to replay it for the retries.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	rec.body.Write(b)
+
+	return rec.ResponseWriter.Write(b)
+}
+
+func (s *server) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPatch, http.MethodDelete:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := s.contextGetUser(r)
+
+		// The keys are scoped to the users, so they are ignored for the anonymous requests.
+		if key == "" || user.IsAnonymous() {
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		s.limiter.mu.Lock()
+		if len(key) > 255 {
+			response.BadRequestResponse(w, r, errors.New("the Idempotency-Key header must not be more than 255 characters long"))
+			return
+		}
 
-		if _, found := s.limiter.clients[ip]; !found {
-			s.limiter.clients[ip] = &client{limiter: rate.NewLimiter(4, 6)}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1_048_576+1))
+		if err != nil {
+			response.BadRequestResponse(w, r, err)
+			return
 		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
 
-		s.limiter.clients[ip].lastSeen = time.Now()
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
 
-		if !s.limiter.clients[ip].limiter.Allow() {
-			s.limiter.mu.Unlock()
-			response.RateLimitExceededResponse(w, r)
+		stored, err := s.cache.Idempotency.Start(user.ID, key, requestHash)
+		if err != nil {
+			response.ServerErrorResponse(w, r, s.logger, err)
 			return
 		}
 
-		s.limiter.mu.Unlock()
+		if stored != nil {
+			switch {
+			case stored.RequestHash != requestHash:
+				response.IdempotencyKeyMismatchResponse(w, r)
+			case !stored.Completed:
+				response.IdempotencyKeyInProgressResponse(w, r)
+			default:
+				for name, values := range stored.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+			}
+			return
+		}
 
-		next.ServeHTTP(w, r)
-	})
-}
+		rec := &idempotencyRecorder{ResponseWriter: w}
 
-func (s *server) recoverPanic(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
-			if err := recover(); err != nil {
-				w.Header().Set("Connection", "close")
-				response.ServerErrorResponse(w, r, s.logger, fmt.Errorf("%s", err))
+			// The server errors are not stored so that the request can be retried.
+			if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+				if err := s.cache.Idempotency.Release(user.ID, key); err != nil {
+					s.logger.WithError(err).Error("an error occurred while releasing the idempotency key")
+				}
+				return
+			}
+
+			res := &cache.IdempotentResponse{
+				RequestHash: requestHash,
+				Status:      rec.status,
+				Header:      w.Header().Clone(),
+				Body:        rec.body.Bytes(),
+			}
+
+			if err := s.cache.Idempotency.Complete(user.ID, key, res); err != nil {
+				s.logger.WithError(err).Error("an error occurred while storing the idempotent response")
 			}
 		}()
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rec, r)
 	})
 }
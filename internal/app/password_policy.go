@@ -0,0 +1,58 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+// newPasswordPolicy returns the default policy with the configured rules. The
+// breached password list is only checked when its path is configured.
+func (s *server) newPasswordPolicy() (*auth.PasswordPolicy, error) {
+	policy := auth.DefaultPasswordPolicy()
+
+	cfg := s.config.PasswordPolicy
+
+	if cfg.MinLength > 0 {
+		policy.MinLength = cfg.MinLength
+	}
+
+	if cfg.MinClasses > 0 {
+		policy.MinClasses = cfg.MinClasses
+	}
+
+	if cfg.MinScore > 0 {
+		policy.MinScore = cfg.MinScore
+	}
+
+	if cfg.DisallowPersonal != nil {
+		policy.DisallowPersonal = *cfg.DisallowPersonal
+	}
+
+	if cfg.BreachedPath != "" {
+		breached, err := auth.NewBreachedPasswords(cfg.BreachedPath)
+		if err != nil {
+			return nil, err
+		}
+
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+// validatePassword responds with the reason if the password does not follow the
+// policy. The personal values are the name and the email of the user.
+func (s *server) validatePassword(w http.ResponseWriter, r *http.Request, password string, personal ...string) bool {
+	if err := s.passwordPolicy.Validate(password, personal...); err != nil {
+		if auth.IsPolicyError(err) {
+			response.FailedValidationResponse(w, r, map[string]string{"password": err.Error()})
+		} else {
+			response.ServerErrorResponse(w, r, s.logger, err)
+		}
+		return false
+	}
+
+	return true
+}
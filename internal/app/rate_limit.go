@@ -0,0 +1,209 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/config"
+	"github.com/nebisin/goExpense/pkg/response"
+)
+
+const (
+	defaultRateLimitPolicy = "default"
+
+	// rateLimitHeaders is the headers of the rate limits which the browsers may read.
+	rateLimitHeaders = "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy"
+)
+
+// defaultRateLimitPolicies are stricter on the routes which guess the passwords,
+// the tokens and the codes or send emails than on the rest of the API.
+var defaultRateLimitPolicies = []config.RateLimitPolicy{
+	{
+		Name:   defaultRateLimitPolicy,
+		Rate:   4,
+		Period: "1s",
+		Burst:  6,
+	},
+	{
+		Name:   "auth",
+		Rate:   10,
+		Period: "1m",
+		Burst:  5,
+		Routes: []string{
+			"POST /api/v1/users",
+			"/api/v1/users/activate",
+			"/api/v1/users/authenticate",
+			"/api/v1/users/authenticate/two-factor",
+			"/api/v1/users/authenticate/magic-link",
+			"/api/v1/users/password",
+			"/api/v1/users/unlock",
+			"/api/v1/users/email",
+			"/api/v1/users/email/cancel",
+			"/api/v1/users/oidc/{provider}",
+			"/api/v1/users/oidc/{provider}/callback",
+			"/api/v1/tokens/password-reset",
+			"/api/v1/tokens/activation",
+			"/api/v1/tokens/refresh",
+			"/api/v1/tokens/magic-link",
+			"/api/v1/invitations/decline",
+		},
+	},
+}
+
+type rateLimitPolicy struct {
+	name  string
+	limit cache.RateLimit
+}
+
+// rateLimitPolicies is the policies of the routes and the default policy of the other routes.
+type rateLimitPolicies struct {
+	routes   map[string]*rateLimitPolicy
+	fallback *rateLimitPolicy
+}
+
+// newRateLimitPolicies returns the built-in policies where the configured
+// policies replace the ones with the same names.
+func newRateLimitPolicies(configured []config.RateLimitPolicy) (*rateLimitPolicies, error) {
+	var all []config.RateLimitPolicy
+
+	for _, policy := range defaultRateLimitPolicies {
+		replaced := false
+		for _, c := range configured {
+			if c.Name == policy.Name {
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			all = append(all, policy)
+		}
+	}
+
+	all = append(all, configured...)
+
+	policies := &rateLimitPolicies{routes: make(map[string]*rateLimitPolicy)}
+
+	for _, c := range all {
+		period, err := time.ParseDuration(c.Period)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit policy %q: %w", c.Name, err)
+		}
+
+		if c.Name == "" || c.Rate <= 0 || period <= 0 || c.Burst <= 0 {
+			return nil, fmt.Errorf("rate limit policy %q must have a name and a positive rate, period and burst", c.Name)
+		}
+
+		policy := &rateLimitPolicy{
+			name:  c.Name,
+			limit: cache.RateLimit{Rate: c.Rate, Period: period, Burst: c.Burst},
+		}
+
+		if c.Name == defaultRateLimitPolicy {
+			policies.fallback = policy
+			continue
+		}
+
+		for _, route := range c.Routes {
+			if _, ok := policies.routes[route]; ok {
+				return nil, fmt.Errorf("route %q has more than one rate limit policy", route)
+			}
+
+			policies.routes[route] = policy
+		}
+	}
+
+	return policies, nil
+}
+
+// match returns the policy of the method and the path template. The routes with
+// a method take precedence over the routes of the same path without a method.
+func (p *rateLimitPolicies) match(method string, path string) *rateLimitPolicy {
+	if policy, ok := p.routes[method+" "+path]; ok {
+		return policy
+	}
+
+	if policy, ok := p.routes[path]; ok {
+		return policy
+	}
+
+	return p.fallback
+}
+
+// rateLimit limits the requests by their IP addresses before they are authenticated, so
+// that the requests with invalid tokens are limited as well. The limits are shared by all
+// the replicas of the server. The requests are allowed when the cache is not available.
+func (s *server) rateLimit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := s.routeRateLimit(r)
+
+		if !s.allowRequest(w, r, policy.name+".ip."+clientIP(r), policy) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitUser limits the requests of the users who logged in by their IDs, so that
+// a user cannot get around the limits by sending the requests from many addresses.
+func (s *server) rateLimitUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.contextGetUser(r)
+
+		if user.IsAnonymous() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy := s.routeRateLimit(r)
+
+		if !s.allowRequest(w, r, policy.name+".user."+strconv.FormatInt(user.ID, 10), policy) {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) routeRateLimit(r *http.Request) *rateLimitPolicy {
+	var path string
+	if route := mux.CurrentRoute(r); route != nil {
+		path, _ = route.GetPathTemplate()
+	}
+
+	return s.rateLimits.match(r.Method, path)
+}
+
+// allowRequest counts the request against the key and sets the headers of the limit.
+// It responds with the time to wait if the limit is exceeded.
+func (s *server) allowRequest(w http.ResponseWriter, r *http.Request, key string, policy *rateLimitPolicy) bool {
+	result, err := s.cache.RateLimit.Allow(key, policy.limit)
+	if err != nil {
+		s.logger.WithFields(map[string]interface{}{
+			"request_method": r.Method,
+			"request_url":    r.URL.String(),
+			"client_ip":      clientIP(r),
+		}).WithError(err).Error("cache error")
+		return true
+	}
+
+	limit := policy.limit
+
+	w.Header().Set("RateLimit-Limit", strconv.FormatInt(limit.Burst, 10))
+	w.Header().Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	w.Header().Set("RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(result.ResetAfter.Seconds())), 10))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d;burst=%d;policy=%s",
+		limit.Rate, int64(math.Ceil(limit.Period.Seconds())), limit.Burst, policy.name))
+
+	if !result.Allowed {
+		response.RateLimitExceededResponse(w, r, result.RetryAfter)
+		return false
+	}
+
+	return true
+}
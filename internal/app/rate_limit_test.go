@@ -0,0 +1,80 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRateLimitPolicies(t *testing.T) {
+	t.Run("built-in policies", func(t *testing.T) {
+		policies, err := newRateLimitPolicies(nil)
+		require.NoError(t, err)
+
+		require.Equal(t, defaultRateLimitPolicy, policies.fallback.name)
+		require.Equal(t, cache.RateLimit{Rate: 4, Period: time.Second, Burst: 6}, policies.fallback.limit)
+		require.Equal(t, "auth", policies.routes["/api/v1/users/authenticate"].name)
+	})
+
+	t.Run("configured policies replace the built-in ones", func(t *testing.T) {
+		policies, err := newRateLimitPolicies([]config.RateLimitPolicy{
+			{Name: defaultRateLimitPolicy, Rate: 100, Period: "1m", Burst: 20},
+			{Name: "auth", Rate: 3, Period: "1m", Burst: 3, Routes: []string{"/api/v1/users/authenticate"}},
+			{Name: "reports", Rate: 1, Period: "1s", Burst: 2, Routes: []string{"GET /api/v1/reports/net-worth"}},
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, cache.RateLimit{Rate: 100, Period: time.Minute, Burst: 20}, policies.fallback.limit)
+		require.Equal(t, cache.RateLimit{Rate: 3, Period: time.Minute, Burst: 3}, policies.routes["/api/v1/users/authenticate"].limit)
+		require.Equal(t, "reports", policies.routes["GET /api/v1/reports/net-worth"].name)
+
+		// The routes of the replaced policy are not kept.
+		require.NotContains(t, policies.routes, "/api/v1/tokens/refresh")
+	})
+
+	invalid := map[string]config.RateLimitPolicy{
+		"invalid period":   {Name: "a", Rate: 1, Period: "soon", Burst: 1},
+		"zero rate":        {Name: "a", Rate: 0, Period: "1s", Burst: 1},
+		"negative period":  {Name: "a", Rate: 1, Period: "-1s", Burst: 1},
+		"zero burst":       {Name: "a", Rate: 1, Period: "1s", Burst: 0},
+		"missing name":     {Rate: 1, Period: "1s", Burst: 1},
+		"duplicated route": {Name: "a", Rate: 1, Period: "1s", Burst: 1, Routes: []string{"/api/v1/users/authenticate"}},
+	}
+
+	for name, policy := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := newRateLimitPolicies([]config.RateLimitPolicy{policy})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRateLimitPolicies_Match(t *testing.T) {
+	policies, err := newRateLimitPolicies([]config.RateLimitPolicy{
+		{Name: "writes", Rate: 1, Period: "1s", Burst: 1, Routes: []string{"POST /api/v1/transactions"}},
+		{Name: "transactions", Rate: 2, Period: "1s", Burst: 2, Routes: []string{"/api/v1/transactions"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{"POST", "/api/v1/users", "auth"},
+		{"PATCH", "/api/v1/users", defaultRateLimitPolicy},
+		{"PUT", "/api/v1/users/activate", "auth"},
+		{"POST", "/api/v1/users/oidc/{provider}", "auth"},
+		{"POST", "/api/v1/transactions", "writes"},
+		{"GET", "/api/v1/transactions", "transactions"},
+		{"GET", "/api/v1/accounts", defaultRateLimitPolicy},
+		{"GET", "", defaultRateLimitPolicy},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, policies.match(tt.method, tt.path).name, tt.method+" "+tt.path)
+	}
+}
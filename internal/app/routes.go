@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/nebisin/goExpense/internal/store"
 	"github.com/nebisin/goExpense/pkg/response"
 )
 
@@ -16,10 +17,14 @@ func (s *server) setupRoutes() {
 
 	s.router.Use(s.rateLimit)
 	s.router.Use(s.authenticate)
+	s.router.Use(s.rateLimitUser)
+	s.router.Use(s.idempotent)
 
 	s.router.NotFoundHandler = http.HandlerFunc(response.NotFoundResponse)
 	s.router.MethodNotAllowedHandler = http.HandlerFunc(response.MethodNotAllowedResponse)
 
+	s.router.HandleFunc("/.well-known/jwks.json", s.handleJWKS).Methods(http.MethodGet)
+
 	apiV1 := s.router.PathPrefix("/api/v1").Subrouter()
 
 	apiV1.HandleFunc("/healthcheck", s.handleHealthCheck)
@@ -30,27 +35,74 @@ func (s *server) setupRoutes() {
 	apiV1.HandleFunc("/users/accounts", s.requireAuthenticatedUser(s.handleGetAccounts)).Methods(http.MethodGet)
 	apiV1.HandleFunc("/users/activate", s.handleActivateUser).Methods(http.MethodPut)
 	apiV1.HandleFunc("/users/authenticate", s.handleLoginUser).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/authenticate/two-factor", s.handleLoginTwoFactor).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/authenticate/magic-link", s.handleLoginMagicLink).Methods(http.MethodPost)
 	apiV1.HandleFunc("/users/password", s.handlePasswordReset).Methods(http.MethodPut)
+	apiV1.HandleFunc("/users/unlock", s.handleUnlockUser).Methods(http.MethodPut)
+	apiV1.HandleFunc("/users/email", s.handleConfirmEmailChange).Methods(http.MethodPut)
+	apiV1.HandleFunc("/users/email/cancel", s.handleCancelEmailChange).Methods(http.MethodPut)
+	apiV1.HandleFunc("/users/logout", s.handleLogout).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/sessions", s.requireAuthenticatedUser(s.handleListSessions)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/users/sessions", s.requireAuthenticatedUser(s.handleRevokeOtherSessions)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/users/sessions/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleRevokeSession)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/users/api-tokens", s.requireAuthenticatedUser(s.handleCreateAPIToken)).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/api-tokens", s.requireAuthenticatedUser(s.handleListAPITokens)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/users/api-tokens/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleRevokeAPIToken)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/users/two-factor", s.requireAuthenticatedUser(s.handleEnrollTwoFactor)).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/two-factor", s.requireAuthenticatedUser(s.handleDisableTwoFactor)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/users/two-factor/activate", s.requireAuthenticatedUser(s.handleActivateTwoFactor)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/users/oidc/{provider}", s.handleStartOIDCLogin).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/oidc/{provider}/callback", s.handleOIDCLogin).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/identities", s.requireAuthenticatedUser(s.handleListIdentities)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/users/identities/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleUnlinkIdentity)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/users/identities/{provider}", s.requireAuthenticatedUser(s.handleLinkIdentity)).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/identities/{provider}/callback", s.requireAuthenticatedUser(s.handleCompleteLinkIdentity)).Methods(http.MethodPost)
+	apiV1.HandleFunc("/users/two-factor/recovery-codes", s.requireAuthenticatedUser(s.handleRegenerateRecoveryCodes)).Methods(http.MethodPost)
 
 	apiV1.HandleFunc("/tokens/password-reset", s.handleCreatePasswordResetToken).Methods(http.MethodPost)
 	apiV1.HandleFunc("/tokens/activation", s.handleNewActivationToken).Methods(http.MethodPost)
-
-	apiV1.HandleFunc("/transactions", s.requireAuthenticatedUser(s.handleCreateTransaction)).Methods(http.MethodPost)
-	apiV1.HandleFunc("/transactions/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleDeleteTransaction)).Methods(http.MethodDelete)
-	apiV1.HandleFunc("/transactions/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleUpdateTransaction)).Methods(http.MethodPatch)
-	apiV1.HandleFunc("/transactions/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleGetTransaction)).Methods(http.MethodGet)
-	apiV1.HandleFunc("/transactions", s.requireAuthenticatedUser(s.handleListTransactions)).Methods(http.MethodGet)
-
-	apiV1.HandleFunc("/accounts", s.requireAuthenticatedUser(s.handleCreateAccount)).Methods(http.MethodPost)
-	apiV1.HandleFunc("/accounts/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleGetAccount)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/tokens/refresh", s.handleRefreshToken).Methods(http.MethodPost)
+	apiV1.HandleFunc("/tokens/magic-link", s.handleCreateMagicLinkToken).Methods(http.MethodPost)
+
+	apiV1.HandleFunc("/invitations/accept", s.requireAuthenticatedUser(s.handleAcceptInvitation)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/invitations/decline", s.handleDeclineInvitation).Methods(http.MethodPut)
+
+	apiV1.HandleFunc("/transactions", s.requireScope(store.APIScopeTransactionsWrite, s.handleCreateTransaction)).Methods(http.MethodPost)
+	apiV1.HandleFunc("/transactions/batch", s.requireScope(store.APIScopeTransactionsWrite, s.handleBatchTransactions)).Methods(http.MethodPost)
+	apiV1.HandleFunc("/transactions/{id:[0-9]+}", s.requireScope(store.APIScopeTransactionsWrite, s.handleDeleteTransaction)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/transactions/{id:[0-9]+}", s.requireScope(store.APIScopeTransactionsWrite, s.handleUpdateTransaction)).Methods(http.MethodPatch)
+	apiV1.HandleFunc("/transactions/{id:[0-9]+}", s.requireScope(store.APIScopeTransactionsRead, s.handleGetTransaction)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/transactions/{id:[0-9]+}/revisions", s.requireScope(store.APIScopeTransactionsRead, s.handleListRevisions)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/transactions/{id:[0-9]+}/revisions/{version:[0-9]+}/revert", s.requireScope(store.APIScopeTransactionsWrite, s.handleRevertTransaction)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/transactions/{id:[0-9]+}/restore", s.requireScope(store.APIScopeTransactionsWrite, s.handleRestoreTransaction)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/transactions", s.requireScope(store.APIScopeTransactionsRead, s.requireAllAccounts(s.handleListTransactions))).Methods(http.MethodGet)
+
+	apiV1.HandleFunc("/accounts", s.requireScope(store.APIScopeAccountsWrite, s.requireAllAccounts(s.handleCreateAccount))).Methods(http.MethodPost)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}", s.requireScope(store.APIScopeAccountsRead, s.handleGetAccount)).Methods(http.MethodGet)
 	apiV1.HandleFunc("/accounts/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleDeleteAccount)).Methods(http.MethodDelete)
-	apiV1.HandleFunc("/accounts/{id:[0-9]+}", s.requireAuthenticatedUser(s.handleUpdateAccount)).Methods(http.MethodPatch)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}", s.requireScope(store.APIScopeAccountsWrite, s.handleUpdateAccount)).Methods(http.MethodPatch)
 	apiV1.HandleFunc("/accounts/{id:[0-9]+}/users", s.requireAuthenticatedUser(s.handleAddUser)).Methods(http.MethodPatch)
-	apiV1.HandleFunc("/accounts/{id:[0-9]+}/users", s.requireAuthenticatedUser(s.handleGetUsers)).Methods(http.MethodGet)
-	apiV1.HandleFunc("/accounts", s.requireAuthenticatedUser(s.handleListAccounts)).Methods(http.MethodGet)
-
-	apiV1.HandleFunc("/accounts/{id:[0-9]+}/transactions", s.requireAuthenticatedUser(s.handleListTransactionsByAccount)).Methods(http.MethodGet)
-	apiV1.HandleFunc("/accounts/{id:[0-9]+}/statistics", s.requireAuthenticatedUser(s.handleListStatisticsByAccount)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/users", s.requireScope(store.APIScopeAccountsRead, s.handleGetUsers)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/users/{userID:[0-9]+}/role", s.requireAuthenticatedUser(s.handleUpdateMemberRole)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/users/{userID:[0-9]+}", s.requireAuthenticatedUser(s.handleRemoveUser)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/users/me", s.requireAuthenticatedUser(s.handleLeaveAccount)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/owner", s.requireAuthenticatedUser(s.handleTransferOwnership)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/invitations", s.requireAuthenticatedUser(s.handleListInvitations)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/invitations/{email}", s.requireAuthenticatedUser(s.handleRevokeInvitation)).Methods(http.MethodDelete)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/restore", s.requireAuthenticatedUser(s.handleRestoreAccount)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/accounts", s.requireScope(store.APIScopeAccountsRead, s.requireAllAccounts(s.handleListAccounts))).Methods(http.MethodGet)
+
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/transactions", s.requireScope(store.APIScopeTransactionsRead, s.handleListTransactionsByAccount)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/statistics", s.requireScope(store.APIScopeAccountsRead, s.handleListStatisticsByAccount)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/forecast", s.requireScope(store.APIScopeAccountsRead, s.handleGetForecastByAccount)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/anomalies", s.requireScope(store.APIScopeAccountsRead, s.handleListAnomaliesByAccount)).Methods(http.MethodGet)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/anomalies/{anomalyID:[0-9]+}/dismiss", s.requireScope(store.APIScopeAccountsWrite, s.handleDismissAnomaly)).Methods(http.MethodPut)
+	apiV1.HandleFunc("/accounts/{id:[0-9]+}/activity", s.requireScope(store.APIScopeAccountsRead, s.handleListActivityByAccount)).Methods(http.MethodGet)
+
+	apiV1.HandleFunc("/trash", s.requireAuthenticatedUser(s.handleListTrash)).Methods(http.MethodGet)
+
+	apiV1.HandleFunc("/reports/net-worth", s.requireScope(store.APIScopeAccountsRead, s.requireAllAccounts(s.handleGetNetWorth))).Methods(http.MethodGet)
+	apiV1.HandleFunc("/reports/cash-flow", s.requireScope(store.APIScopeAccountsRead, s.requireAllAccounts(s.handleGetCashFlow))).Methods(http.MethodGet)
 }
 
 func (s *server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
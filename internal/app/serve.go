@@ -14,7 +14,7 @@ import (
 func (s *server) serve() error {
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.config.Port),
-		Handler:      s.recoverPanic(s.enableCORS(s.router)),
+		Handler:      s.recoverPanic(s.realIP.Handler(s.enableCORS(s.router))),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 		IdleTimeout:  time.Minute,
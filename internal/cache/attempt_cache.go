@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// AttemptPolicy is how the failed attempts of a key are limited. The key is blocked
+// with an exponentially growing delay after the free attempts and locked out after
+// the lockout attempts. The failures are forgotten after the window without a failure.
+type AttemptPolicy struct {
+	FreeAttempts    int64
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutAfter    int64
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+// Delay returns how long the key is blocked after the failures.
+func (p AttemptPolicy) Delay(failures int64) time.Duration {
+	if p.IsLockout(failures) {
+		return p.LockoutDuration
+	}
+
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay
+}
+
+// IsLockout reports whether the failures lock the key out.
+func (p AttemptPolicy) IsLockout(failures int64) bool {
+	return p.LockoutAfter > 0 && failures >= p.LockoutAfter
+}
+
+// AttemptCache counts the failed attempts of the keys such as the
+// accounts and the IP addresses to slow down the guessing attacks.
+type AttemptCache struct {
+	rdb *redis.Client
+}
+
+func NewAttemptCache(rdb *redis.Client) *AttemptCache {
+	return &AttemptCache{rdb: rdb}
+}
+
+func attemptKey(key string) string {
+	return fmt.Sprintf("attempts.%s", key)
+}
+
+func attemptBlockKey(key string) string {
+	return fmt.Sprintf("attempts.%s.blocked", key)
+}
+
+// Blocked returns the longest time any of the keys is still blocked for.
+func (c *AttemptCache) Blocked(keys ...string) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	pipe := c.rdb.Pipeline()
+
+	cmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.PTTL(ctx, attemptBlockKey(key))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	var blocked time.Duration
+	for _, cmd := range cmds {
+		// The missing keys have a negative TTL.
+		if ttl := cmd.Val(); ttl > blocked {
+			blocked = ttl
+		}
+	}
+
+	return blocked, nil
+}
+
+// Fail counts a failed attempt of the key and blocks the key for the delay
+// of the policy. It returns the number of the failures in the window.
+func (c *AttemptCache) Fail(key string, policy AttemptPolicy) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	pipe := c.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, attemptKey(key))
+	pipe.Expire(ctx, attemptKey(key), policy.Window)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	failures := incr.Val()
+
+	if delay := policy.Delay(failures); delay > 0 {
+		if err := c.rdb.Set(ctx, attemptBlockKey(key), failures, delay).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return failures, nil
+}
+
+// Reset forgets the failures of the keys and unblocks them.
+func (c *AttemptCache) Reset(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	redisKeys := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		redisKeys = append(redisKeys, attemptKey(key), attemptBlockKey(key))
+	}
+
+	return c.rdb.Del(ctx, redisKeys...).Err()
+}
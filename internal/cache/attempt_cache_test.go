@@ -0,0 +1,72 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttemptPolicy_Delay(t *testing.T) {
+	policy := cache.AttemptPolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        10 * time.Second,
+		LockoutAfter:    10,
+		LockoutDuration: time.Hour,
+	}
+
+	expected := map[int64]time.Duration{
+		1:  0,
+		3:  0,
+		4:  time.Second,
+		5:  2 * time.Second,
+		6:  4 * time.Second,
+		7:  8 * time.Second,
+		8:  10 * time.Second,
+		9:  10 * time.Second,
+		10: time.Hour,
+		11: time.Hour,
+	}
+
+	for failures, delay := range expected {
+		require.Equal(t, delay, policy.Delay(failures), failures)
+		require.Equal(t, failures >= 10, policy.IsLockout(failures), failures)
+	}
+}
+
+func TestAttemptCache(t *testing.T) {
+	key := "test." + random.String(16)
+	other := "test." + random.String(16)
+
+	policy := cache.AttemptPolicy{FreeAttempts: 1, BaseDelay: time.Minute, MaxDelay: time.Hour, Window: time.Hour}
+
+	failures, err := testCache.Attempts.Fail(key, policy)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), failures)
+
+	blocked, err := testCache.Attempts.Blocked(key, other)
+	require.NoError(t, err)
+	require.Zero(t, blocked)
+
+	failures, err = testCache.Attempts.Fail(key, policy)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), failures)
+
+	blocked, err = testCache.Attempts.Blocked(other, key)
+	require.NoError(t, err)
+	require.InDelta(t, time.Minute, blocked, float64(time.Second))
+
+	err = testCache.Attempts.Reset(key)
+	require.NoError(t, err)
+
+	blocked, err = testCache.Attempts.Blocked(key)
+	require.NoError(t, err)
+	require.Zero(t, blocked)
+
+	failures, err = testCache.Attempts.Fail(key, policy)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), failures)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const IdempotencyTTL = 24 * time.Hour
+
+// IdempotentResponse is the response of the first request made with an idempotency key.
+// It is stored without the status and the body while the first request is in progress.
+type IdempotentResponse struct {
+	RequestHash string      `json:"requestHash"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+}
+
+type IdempotencyCache struct {
+	rdb *redis.Client
+}
+
+func NewIdempotencyCache(rdb *redis.Client) *IdempotencyCache {
+	return &IdempotencyCache{rdb: rdb}
+}
+
+func idempotencyKey(userID int64, key string) string {
+	return fmt.Sprintf("idempotency.%d.%s", userID, key)
+}
+
+// Start reserves the key for the request. It returns nil if the key is reserved
+// and the stored response if the key was already used by another request.
+func (c *IdempotencyCache) Start(userID int64, key string, requestHash string) (*IdempotentResponse, error) {
+	val, err := json.Marshal(&IdempotentResponse{RequestHash: requestHash})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	ok, err := c.rdb.SetNX(ctx, idempotencyKey(userID, key), val, IdempotencyTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		return nil, nil
+	}
+
+	stored, err := c.rdb.Get(ctx, idempotencyKey(userID, key)).Bytes()
+	switch {
+	case err == redis.Nil:
+		// The key is expired right after the reservation has failed.
+		return c.Start(userID, key, requestHash)
+	case err != nil:
+		return nil, err
+	}
+
+	var res IdempotentResponse
+	if err := json.Unmarshal(stored, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Complete stores the response of the request for the replays.
+func (c *IdempotencyCache) Complete(userID int64, key string, res *IdempotentResponse) error {
+	res.Completed = true
+
+	val, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return c.rdb.Set(ctx, idempotencyKey(userID, key), val, IdempotencyTTL).Err()
+}
+
+// Release removes the reservation so that the request can be retried.
+func (c *IdempotencyCache) Release(userID int64, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return c.rdb.Del(ctx, idempotencyKey(userID, key)).Err()
+}
@@ -0,0 +1,55 @@
+package cache_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyCache(t *testing.T) {
+	userID := random.Int(1, 1000)
+	key := random.String(24)
+
+	res, err := testCache.Idempotency.Start(userID, key, "hash")
+	require.NoError(t, err)
+	require.Nil(t, res)
+
+	t.Run("in progress case for idempotency key", func(t *testing.T) {
+		res, err := testCache.Idempotency.Start(userID, key, "hash")
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		require.False(t, res.Completed)
+		require.Equal(t, "hash", res.RequestHash)
+	})
+
+	stored := &cache.IdempotentResponse{
+		RequestHash: "hash",
+		Status:      http.StatusCreated,
+		Header:      http.Header{"Content-Type": {"application/json"}},
+		Body:        []byte(`{"id":1}`),
+	}
+
+	err = testCache.Idempotency.Complete(userID, key, stored)
+	require.NoError(t, err)
+
+	t.Run("completed case for idempotency key", func(t *testing.T) {
+		res, err := testCache.Idempotency.Start(userID, key, "hash")
+		require.NoError(t, err)
+		require.NotNil(t, res)
+		require.True(t, res.Completed)
+		require.Equal(t, http.StatusCreated, res.Status)
+		require.Equal(t, stored.Body, res.Body)
+	})
+
+	t.Run("released case for idempotency key", func(t *testing.T) {
+		err := testCache.Idempotency.Release(userID, key)
+		require.NoError(t, err)
+
+		res, err := testCache.Idempotency.Start(userID, key, "other")
+		require.NoError(t, err)
+		require.Nil(t, res)
+	})
+}
@@ -11,13 +11,23 @@ var (
 )
 
 type Cache struct {
-	RDB  *redis.Client
-	User *UserCache
+	RDB         *redis.Client
+	User        *UserCache
+	Idempotency *IdempotencyCache
+	Session     *SessionCache
+	OIDC        *OIDCCache
+	Attempts    *AttemptCache
+	RateLimit   *RateLimitCache
 }
 
 func NewCache(rdb *redis.Client) *Cache {
 	return &Cache{
-		RDB:  rdb,
-		User: NewUserCache(rdb),
+		RDB:         rdb,
+		User:        NewUserCache(rdb),
+		Idempotency: NewIdempotencyCache(rdb),
+		Session:     NewSessionCache(rdb),
+		OIDC:        NewOIDCCache(rdb),
+		Attempts:    NewAttemptCache(rdb),
+		RateLimit:   NewRateLimitCache(rdb),
 	}
 }
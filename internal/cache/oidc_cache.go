@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const OIDCStateTTL = 10 * time.Minute
+
+// OIDCState is the state of an authorization code flow which is started
+// on the server. The user ID is only set when the flow links an identity.
+type OIDCState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+	UserID   int64  `json:"userID,omitempty"`
+}
+
+type OIDCCache struct {
+	rdb *redis.Client
+}
+
+func NewOIDCCache(rdb *redis.Client) *OIDCCache {
+	return &OIDCCache{rdb: rdb}
+}
+
+func oidcStateKey(state string) string {
+	return fmt.Sprintf("oidc.%s", state)
+}
+
+func (c *OIDCCache) Add(state string, s *OIDCState) error {
+	val, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return c.rdb.Set(ctx, oidcStateKey(state), val, OIDCStateTTL).Err()
+}
+
+// Pop returns and removes the state so that a state can only be used once.
+func (c *OIDCCache) Pop(state string) (*OIDCState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	var get *redis.StringCmd
+
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, oidcStateKey(state))
+		pipe.Del(ctx, oidcStateKey(state))
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	val, err := get.Bytes()
+	switch {
+	case err == redis.Nil:
+		return nil, ErrRecordNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	var s OIDCState
+	if err := json.Unmarshal(val, &s); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,29 @@
+package cache_test
+
+import (
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCCache(t *testing.T) {
+	state := random.String(32)
+
+	_, err := testCache.OIDC.Pop(state)
+	require.ErrorIs(t, err, cache.ErrRecordNotFound)
+
+	err = testCache.OIDC.Add(state, &cache.OIDCState{Provider: "google", Verifier: "verifier", Nonce: "nonce", UserID: 7})
+	require.NoError(t, err)
+
+	got, err := testCache.OIDC.Pop(state)
+	require.NoError(t, err)
+	require.Equal(t, "google", got.Provider)
+	require.Equal(t, "verifier", got.Verifier)
+	require.Equal(t, "nonce", got.Nonce)
+	require.Equal(t, int64(7), got.UserID)
+
+	_, err = testCache.OIDC.Pop(state)
+	require.ErrorIs(t, err, cache.ErrRecordNotFound)
+}
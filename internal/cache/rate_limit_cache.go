@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RateLimit allows Rate requests in every Period with bursts of up to Burst requests.
+type RateLimit struct {
+	Rate   int64
+	Period time.Duration
+	Burst  int64
+}
+
+// emissionInterval is the time between the requests at the rate.
+func (l RateLimit) emissionInterval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// RateLimitResult is the state of a key after a request.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int64
+	// RetryAfter is how long the client has to wait for the next request if it is not allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long it takes for the whole burst to be available again.
+	ResetAfter time.Duration
+}
+
+// gcraScript implements the generic cell rate algorithm. The key stores the theoretical
+// arrival time of the next request in milliseconds of the redis clock, so that the
+// replicas of the server with different clocks share the same limit. The times are
+// formatted by hand since Lua would print them in the exponent notation.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + tonumber(time[2]) / 1000
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local newTat = tat + emission
+local diff = now - (newTat - emission * burst)
+
+if diff < 0 then
+	return {0, 0, math.ceil(-diff), math.ceil(tat - now)}
+end
+
+redis.call("SET", KEYS[1], string.format("%.3f", newTat), "PX", math.ceil(newTat - now))
+
+return {1, math.floor(diff / emission), 0, math.ceil(newTat - now)}
+`)
+
+// RateLimitCache limits the requests of the keys such as the users and the IP
+// addresses across all the replicas of the server.
+type RateLimitCache struct {
+	rdb *redis.Client
+}
+
+func NewRateLimitCache(rdb *redis.Client) *RateLimitCache {
+	return &RateLimitCache{rdb: rdb}
+}
+
+func rateLimitKey(key string) string {
+	return fmt.Sprintf("rate-limit.%s", key)
+}
+
+// Allow counts a request of the key if the limit allows it.
+func (c *RateLimitCache) Allow(key string, limit RateLimit) (*RateLimitResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	emission := float64(limit.emissionInterval()) / float64(time.Millisecond)
+
+	args := []interface{}{strconv.FormatFloat(emission, 'f', 3, 64), limit.Burst}
+
+	values, err := gcraScript.Run(ctx, c.rdb, []string{rateLimitKey(key)}, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  values[1],
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
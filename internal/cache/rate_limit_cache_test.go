@@ -0,0 +1,36 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitCache(t *testing.T) {
+	key := "test." + random.String(16)
+	limit := cache.RateLimit{Rate: 1, Period: time.Hour, Burst: 3}
+
+	for i := int64(2); i >= 0; i-- {
+		result, err := testCache.RateLimit.Allow(key, limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+		require.Zero(t, result.RetryAfter)
+	}
+
+	result, err := testCache.RateLimit.Allow(key, limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Zero(t, result.Remaining)
+	require.InDelta(t, time.Hour, result.RetryAfter, float64(time.Second))
+	require.InDelta(t, 3*time.Hour, result.ResetAfter, float64(time.Second))
+
+	// The other keys have their own limits.
+	result, err = testCache.RateLimit.Allow("test."+random.String(16), limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, int64(2), result.Remaining)
+}
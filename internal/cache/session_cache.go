@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	sessionActive  = "active"
+	sessionRevoked = "revoked"
+)
+
+// SessionCache keeps whether the sessions are revoked. The database is the source
+// of truth, so a missing session is looked up and added to the cache again.
+type SessionCache struct {
+	rdb *redis.Client
+}
+
+func NewSessionCache(rdb *redis.Client) *SessionCache {
+	return &SessionCache{rdb: rdb}
+}
+
+func sessionKey(id int64) string {
+	return fmt.Sprintf("sessions.%d", id)
+}
+
+// IsRevoked returns ErrRecordNotFound if the session is not in the cache.
+func (c *SessionCache) IsRevoked(id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	val, err := c.rdb.Get(ctx, sessionKey(id)).Result()
+	switch {
+	case err == redis.Nil:
+		return false, ErrRecordNotFound
+	case err != nil:
+		return false, err
+	case val == "":
+		return false, ErrRecordNotFound
+	}
+
+	return val == sessionRevoked, nil
+}
+
+// Add adds the state of the session which is read from the database. It does not
+// overwrite the state, so a revocation in the meantime is not lost.
+func (c *SessionCache) Add(id int64, revoked bool) error {
+	val := sessionActive
+	if revoked {
+		val = sessionRevoked
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return c.rdb.SetNX(ctx, sessionKey(id), val, time.Minute*12).Err()
+}
+
+func (c *SessionCache) Revoke(ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	pipe := c.rdb.Pipeline()
+	for _, id := range ids {
+		pipe.Set(ctx, sessionKey(id), sessionRevoked, time.Minute*12)
+	}
+
+	_, err := pipe.Exec(ctx)
+
+	return err
+}
+
+// ShouldTouch reports whether the last used time of the session was not
+// updated within the interval and reserves the update for the caller.
+func (c *SessionCache) ShouldTouch(id int64, interval time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	return c.rdb.SetNX(ctx, sessionKey(id)+".touched", 1, interval).Result()
+}
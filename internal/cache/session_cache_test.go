@@ -0,0 +1,46 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/cache"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionCache(t *testing.T) {
+	id := random.Int(1, 1000000)
+
+	_, err := testCache.Session.IsRevoked(id)
+	require.ErrorIs(t, err, cache.ErrRecordNotFound)
+
+	err = testCache.Session.Add(id, false)
+	require.NoError(t, err)
+
+	revoked, err := testCache.Session.IsRevoked(id)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = testCache.Session.Revoke(id)
+	require.NoError(t, err)
+
+	t.Run("add does not overwrite the revocation", func(t *testing.T) {
+		err := testCache.Session.Add(id, false)
+		require.NoError(t, err)
+
+		revoked, err := testCache.Session.IsRevoked(id)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+
+	t.Run("touch is reserved once in the interval", func(t *testing.T) {
+		ok, err := testCache.Session.ShouldTouch(id, time.Minute)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = testCache.Session.ShouldTouch(id, time.Minute)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,94 @@
+package forecast
+
+import (
+	"math"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+)
+
+// z is the two sided z-score of the 95% confidence band.
+const z = 1.96
+
+type Day struct {
+	Date     time.Time `json:"date"`
+	Expected float64   `json:"expected"`
+	Lower    float64   `json:"lower"`
+	Upper    float64   `json:"upper"`
+}
+
+type Result struct {
+	Balance       float64    `json:"balance"`
+	DailyEarning  float64    `json:"dailyEarning"`
+	DailySpending float64    `json:"dailySpending"`
+	FirstNegative *time.Time `json:"firstNegative"`
+	Days          []Day      `json:"days"`
+}
+
+// Project projects the balance of an account day by day from the day
+// after start until the end date. The regular daily earning and spending
+// are estimated from the statistics of the last historyDays days before
+// start, and the scheduled statistics are the known future-dated
+// transactions which are added on their own dates.
+func Project(balance float64, history []*store.Statistic, scheduled []*store.Statistic, start time.Time, end time.Time, historyDays int) Result {
+	start = truncate(start)
+	end = truncate(end)
+
+	result := Result{Balance: balance, Days: []Day{}}
+
+	nets := make(map[string]float64)
+	for _, stat := range history {
+		result.DailyEarning += stat.Earning
+		result.DailySpending += stat.Spending
+		nets[key(stat.Date)] += stat.Earning - stat.Spending
+	}
+
+	var mean, deviation float64
+	if historyDays > 0 {
+		result.DailyEarning /= float64(historyDays)
+		result.DailySpending /= float64(historyDays)
+		mean = result.DailyEarning - result.DailySpending
+
+		// The days without statistics count as days with no activity.
+		var variance float64
+		for day := start.AddDate(0, 0, -historyDays); day.Before(start); day = day.AddDate(0, 0, 1) {
+			variance += math.Pow(nets[key(day)]-mean, 2)
+		}
+		deviation = math.Sqrt(variance / float64(historyDays))
+	}
+
+	known := make(map[string]float64)
+	for _, stat := range scheduled {
+		known[key(stat.Date)] += stat.Earning - stat.Spending
+	}
+
+	expected := balance
+	k := 0
+	for day := start.AddDate(0, 0, 1); !day.After(end); day = day.AddDate(0, 0, 1) {
+		k++
+		expected += mean + known[key(day)]
+		band := z * deviation * math.Sqrt(float64(k))
+
+		result.Days = append(result.Days, Day{
+			Date:     day,
+			Expected: expected,
+			Lower:    expected - band,
+			Upper:    expected + band,
+		})
+
+		if expected < 0 && result.FirstNegative == nil {
+			date := day
+			result.FirstNegative = &date
+		}
+	}
+
+	return result
+}
+
+func truncate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func key(t time.Time) string {
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,71 @@
+package forecast_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/forecast"
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestProject(t *testing.T) {
+	start := date(2021, time.March, 10)
+
+	t.Run("steady spending case for project", func(t *testing.T) {
+		history := []*store.Statistic{}
+		for i := 1; i <= 10; i++ {
+			history = append(history, &store.Statistic{Date: start.AddDate(0, 0, -i), Spending: 10})
+		}
+
+		result := forecast.Project(35, history, nil, start, start.AddDate(0, 0, 5), 10)
+
+		require.Len(t, result.Days, 5)
+		require.Equal(t, float64(10), result.DailySpending)
+		require.Equal(t, float64(25), result.Days[0].Expected)
+		require.Equal(t, float64(-15), result.Days[4].Expected)
+
+		// Constant spending has no deviation so the band collapses.
+		require.Equal(t, result.Days[4].Expected, result.Days[4].Lower)
+		require.Equal(t, result.Days[4].Expected, result.Days[4].Upper)
+
+		require.NotNil(t, result.FirstNegative)
+		require.Equal(t, start.AddDate(0, 0, 4), *result.FirstNegative)
+	})
+
+	t.Run("scheduled transactions case for project", func(t *testing.T) {
+		scheduled := []*store.Statistic{
+			{Date: start.AddDate(0, 0, 2), Spending: 150},
+			{Date: start.AddDate(0, 0, 3), Earning: 200},
+		}
+
+		result := forecast.Project(100, nil, scheduled, start, start.AddDate(0, 0, 3), 0)
+
+		require.Len(t, result.Days, 3)
+		require.Equal(t, float64(100), result.Days[0].Expected)
+		require.Equal(t, float64(-50), result.Days[1].Expected)
+		require.Equal(t, float64(150), result.Days[2].Expected)
+
+		require.NotNil(t, result.FirstNegative)
+		require.Equal(t, start.AddDate(0, 0, 2), *result.FirstNegative)
+	})
+
+	t.Run("irregular spending widens the band", func(t *testing.T) {
+		history := []*store.Statistic{
+			{Date: start.AddDate(0, 0, -1), Spending: 40},
+			{Date: start.AddDate(0, 0, -3), Earning: 20},
+		}
+
+		result := forecast.Project(1000, history, nil, start, start.AddDate(0, 0, 2), 4)
+
+		require.Len(t, result.Days, 2)
+		require.Nil(t, result.FirstNegative)
+		require.Less(t, result.Days[0].Lower, result.Days[0].Expected)
+		require.Greater(t, result.Days[0].Upper, result.Days[0].Expected)
+		require.Greater(t, result.Days[1].Upper-result.Days[1].Lower, result.Days[0].Upper-result.Days[0].Lower)
+	})
+}
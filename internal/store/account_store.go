@@ -9,15 +9,16 @@ import (
 )
 
 type Account struct {
-	ID           int64     `json:"id"`
-	OwnerID      int64     `json:"ownerID"`
-	Title        string    `json:"title"`
-	Description  string    `json:"description,omitempty"`
-	TotalIncome  float64   `json:"totalIncome"`
-	TotalExpense float64   `json:"totalExpense"`
-	Currency     string    `json:"currency"`
-	CreatedAt    time.Time `json:"createdAt"`
-	Version      int       `json:"version"`
+	ID           int64      `json:"id"`
+	OwnerID      int64      `json:"ownerID"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	TotalIncome  float64    `json:"totalIncome"`
+	TotalExpense float64    `json:"totalExpense"`
+	Currency     string     `json:"currency"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	Version      int        `json:"version"`
+	DeletedAt    *time.Time `json:"deletedAt,omitempty"`
 }
 
 type accountModel struct {
@@ -47,7 +48,7 @@ RETURNING id, created_at, version`
 func (m *accountModel) Get(id int64) (*Account, error) {
 	query := `SELECT id, owner_id, title, description, total_income, total_expense, currency, created_at, version
 FROM accounts
-WHERE id=$1`
+WHERE id=$1 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -73,8 +74,8 @@ WHERE id=$1`
 }
 
 func (m *accountModel) Delete(id int64, ownerID int64) error {
-	query := `DELETE FROM accounts
-WHERE id=$1 AND owner_id=$2`
+	query := `UPDATE accounts SET deleted_at = now()
+WHERE id=$1 AND owner_id=$2 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -97,11 +98,12 @@ WHERE id=$1 AND owner_id=$2`
 }
 
 func (m *accountModel) Update(account *Account) error {
-	query := `UPDATE accounts SET title=$1, description=$2, total_income=$3, total_expense=$4, currency=$5, version=version+1
-WHERE id=$6 AND version=$7
+	query := `UPDATE accounts SET owner_id=$1, title=$2, description=$3, total_income=$4, total_expense=$5, currency=$6, version=version+1
+WHERE id=$7 AND version=$8
 RETURNING version`
 
 	args := []interface{}{
+		account.OwnerID,
 		account.Title,
 		account.Description,
 		account.TotalIncome,
@@ -125,7 +127,7 @@ RETURNING version`
 func (m *accountModel) GetAll(ownerID int64, filters Filters) ([]*Account, error) {
 	query := fmt.Sprintf(`SELECT id, owner_id, title, description, total_income, total_expense, currency, created_at, version
 FROM accounts
-WHERE owner_id=$1
+WHERE owner_id=$1 AND deleted_at IS NULL
 ORDER BY %s %s, id ASC
 LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
 
@@ -168,14 +170,14 @@ LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
 	return accounts, nil
 }
 
-func (m *accountModel) AddUser(userID int64, accountID int64) error {
-	query := `INSERT INTO users_accounts (user_id, account_id)
-	VALUES ($1, $2)`
+func (m *accountModel) AddUser(userID int64, accountID int64, role string) error {
+	query := `INSERT INTO users_accounts (user_id, account_id, role)
+	VALUES ($1, $2, $3)`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	_, err := m.DB.ExecContext(ctx, query, userID, accountID)
+	_, err := m.DB.ExecContext(ctx, query, userID, accountID, role)
 
 	return err
 }
@@ -204,8 +206,8 @@ func (m *accountModel) RemoveUser(userID int64, accountID int64) error {
 	return nil
 }
 
-func (m *accountModel) GetUsers(accountID int64) ([]*User, error) {
-	query := `SELECT u.id, u.email, u.name, u.created_at, u.version
+func (m *accountModel) GetUsers(accountID int64) ([]*Member, error) {
+	query := `SELECT u.id, u.email, u.name, u.created_at, u.version, a.role
 	FROM users_accounts a
 	LEFT JOIN users u ON a.user_id = u.id
 	WHERE account_id=$1`
@@ -219,10 +221,11 @@ func (m *accountModel) GetUsers(accountID int64) ([]*User, error) {
 	}
 	defer rows.Close()
 
-	users := []*User{}
+	users := []*Member{}
 
 	for rows.Next() {
 		var user User
+		var role string
 
 		err := rows.Scan(
 			&user.ID,
@@ -230,12 +233,13 @@ func (m *accountModel) GetUsers(accountID int64) ([]*User, error) {
 			&user.Name,
 			&user.CreatedAt,
 			&user.Version,
+			&role,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		users = append(users, &user)
+		users = append(users, &Member{User: &user, Role: role})
 	}
 
 	if err := rows.Err(); err != nil {
@@ -244,3 +248,176 @@ func (m *accountModel) GetUsers(accountID int64) ([]*User, error) {
 
 	return users, nil
 }
+
+func (m *accountModel) GetMember(accountID int64, userID int64) (*Member, error) {
+	query := `SELECT u.id, u.email, u.name, u.created_at, u.version, a.role
+	FROM users_accounts a
+	INNER JOIN users u ON a.user_id = u.id
+	INNER JOIN accounts ac ON a.account_id = ac.id
+	WHERE a.account_id=$1 AND a.user_id=$2 AND ac.deleted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user User
+	member := Member{User: &user}
+
+	err := m.DB.QueryRowContext(ctx, query, accountID, userID).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Name,
+		&user.CreatedAt,
+		&user.Version,
+		&member.Role,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (m *accountModel) UpdateRole(accountID int64, userID int64, role string) error {
+	query := `UPDATE users_accounts SET role=$1
+	WHERE account_id=$2 AND user_id=$3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, role, accountID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// GetDeleted returns the account if it is in the trash.
+func (m *accountModel) GetDeleted(id int64) (*Account, error) {
+	query := `SELECT id, owner_id, title, description, total_income, total_expense, currency, created_at, version, deleted_at
+	FROM accounts
+	WHERE id=$1 AND deleted_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var account Account
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&account.ID,
+		&account.OwnerID,
+		&account.Title,
+		&account.Description,
+		&account.TotalIncome,
+		&account.TotalExpense,
+		&account.Currency,
+		&account.CreatedAt,
+		&account.Version,
+		&account.DeletedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+
+	return &account, err
+}
+
+// GetTrash returns the deleted accounts of the owner from the most recently deleted.
+func (m *accountModel) GetTrash(ownerID int64) ([]*Account, error) {
+	query := `SELECT id, owner_id, title, description, total_income, total_expense, currency, created_at, version, deleted_at
+	FROM accounts
+	WHERE owner_id=$1 AND deleted_at IS NOT NULL
+	ORDER BY deleted_at DESC, id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []*Account{}
+
+	for rows.Next() {
+		var account Account
+
+		err := rows.Scan(
+			&account.ID,
+			&account.OwnerID,
+			&account.Title,
+			&account.Description,
+			&account.TotalIncome,
+			&account.TotalExpense,
+			&account.Currency,
+			&account.CreatedAt,
+			&account.Version,
+			&account.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, &account)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (m *accountModel) Restore(id int64) error {
+	query := `UPDATE accounts SET deleted_at = NULL
+	WHERE id=$1 AND deleted_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Purge removes the accounts which were deleted before the given time
+// with all of their transactions and statistics.
+func (m *accountModel) Purge(before time.Time) (int64, error) {
+	query := `DELETE FROM accounts
+	WHERE deleted_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
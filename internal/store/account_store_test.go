@@ -91,6 +91,58 @@ func TestAccountModel_Delete(t *testing.T) {
 	})
 }
 
+func TestAccountModel_Restore(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	err := testModels.Accounts.Delete(account1.ID, account1.OwnerID)
+	require.NoError(t, err)
+
+	deleted, err := testModels.Accounts.GetDeleted(account1.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+
+	trash, err := testModels.Accounts.GetTrash(account1.OwnerID)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, account1.ID, trash[0].ID)
+
+	t.Run("success case for restore account", func(t *testing.T) {
+		err := testModels.Accounts.Restore(account1.ID)
+		require.NoError(t, err)
+
+		account2, err := testModels.Accounts.Get(account1.ID)
+		require.NoError(t, err)
+		require.Equal(t, account1.ID, account2.ID)
+
+		_, err = testModels.Accounts.GetDeleted(account1.ID)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+
+	t.Run("not deleted case for restore account", func(t *testing.T) {
+		err := testModels.Accounts.Restore(account1.ID)
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
+
+func TestAccountModel_Purge(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	err := testModels.Accounts.Delete(account1.ID, account1.OwnerID)
+	require.NoError(t, err)
+
+	rows, err := testModels.Accounts.Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, rows, int64(1))
+
+	_, err = testModels.Accounts.GetDeleted(account1.ID)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	_, err = testModels.Accounts.Get(account2.ID)
+	require.NoError(t, err)
+}
+
 func TestAccountModel_Update(t *testing.T) {
 
 	t.Run("success case for update account method", func(t *testing.T) {
@@ -146,7 +198,7 @@ func TestAccountModel_GetAll(t *testing.T) {
 func TestAccountModel_RemoveUser(t *testing.T) {
 	account := createRandomAccount(t)
 
-	err := testModels.Accounts.AddUser(account.OwnerID, account.ID)
+	err := testModels.Accounts.AddUser(account.OwnerID, account.ID, store.RoleOwner)
 	require.NoError(t, err)
 
 	users, err := testModels.Accounts.GetUsers(account.ID)
@@ -158,3 +210,80 @@ func TestAccountModel_RemoveUser(t *testing.T) {
 	err = testModels.Accounts.RemoveUser(account.OwnerID, account.ID)
 	require.NoError(t, err)
 }
+
+func TestAccountModel_GetMember(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	err := testModels.Accounts.AddUser(user.ID, account.ID, store.RoleViewer)
+	require.NoError(t, err)
+
+	t.Run("success case for get member", func(t *testing.T) {
+		member, err := testModels.Accounts.GetMember(account.ID, user.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, member)
+
+		require.Equal(t, user.ID, member.ID)
+		require.Equal(t, store.RoleViewer, member.Role)
+		require.True(t, member.Can(store.PermissionReadAccount))
+		require.False(t, member.Can(store.PermissionCreateTransaction))
+	})
+
+	t.Run("not member case for get member", func(t *testing.T) {
+		member, err := testModels.Accounts.GetMember(account.ID, account.OwnerID)
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+		require.Empty(t, member)
+	})
+}
+
+func TestAccountModel_UpdateRole(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+
+	err := testModels.Accounts.AddUser(user.ID, account.ID, store.RoleViewer)
+	require.NoError(t, err)
+
+	err = testModels.Accounts.UpdateRole(account.ID, user.ID, store.RoleEditor)
+	require.NoError(t, err)
+
+	member, err := testModels.Accounts.GetMember(account.ID, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.RoleEditor, member.Role)
+
+	err = testModels.Accounts.UpdateRole(account.ID, account.OwnerID, store.RoleEditor)
+	require.Error(t, err)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestModels_TransferOwnershipTX(t *testing.T) {
+	account := store.Account{}
+	owner := createRandomUser(t)
+	user := createRandomUser(t)
+
+	account.OwnerID = owner.ID
+	account.Title = random.Name()
+	account.Currency = "USD"
+
+	err := testModels.CreateAccountTX(&account, store.Actor{UserID: owner.ID})
+	require.NoError(t, err)
+
+	err = testModels.Accounts.AddUser(user.ID, account.ID, store.RoleEditor)
+	require.NoError(t, err)
+
+	err = testModels.TransferOwnershipTX(&account, user.ID, store.Actor{UserID: owner.ID})
+	require.NoError(t, err)
+	require.Equal(t, user.ID, account.OwnerID)
+
+	updated, err := testModels.Accounts.Get(account.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, updated.OwnerID)
+
+	newOwner, err := testModels.Accounts.GetMember(account.ID, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.RoleOwner, newOwner.Role)
+
+	oldOwner, err := testModels.Accounts.GetMember(account.ID, owner.ID)
+	require.NoError(t, err)
+	require.Equal(t, store.RoleAdmin, oldOwner.Role)
+}
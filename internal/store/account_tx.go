@@ -5,7 +5,13 @@ import (
 	"time"
 )
 
-func (m *Models) CreateAccountTX(account *Account) error {
+// memberAudit is the membership recorded in the audit log.
+type memberAudit struct {
+	UserID int64  `json:"userID"`
+	Role   string `json:"role"`
+}
+
+func (m *Models) CreateAccountTX(account *Account, actor Actor) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -22,7 +28,12 @@ func (m *Models) CreateAccountTX(account *Account) error {
 		return err
 	}
 
-	err = txModels.Accounts.AddUser(account.OwnerID, account.ID)
+	err = txModels.Accounts.AddUser(account.OwnerID, account.ID, RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	err = txModels.AuditLogs.Record(actor, account.ID, AuditCreate, AuditEntityAccount, account.ID, nil, account)
 	if err != nil {
 		return err
 	}
@@ -34,3 +45,188 @@ func (m *Models) CreateAccountTX(account *Account) error {
 	return nil
 
 }
+
+func (m *Models) UpdateAccountTX(account *Account, oldAccount Account, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Accounts.Update(account); err != nil {
+		return err
+	}
+
+	if err := txModels.AuditLogs.Record(actor, account.ID, AuditUpdate, AuditEntityAccount, account.ID, &oldAccount, account); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Models) DeleteAccountTX(account *Account, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Accounts.Delete(account.ID, account.OwnerID); err != nil {
+		return err
+	}
+
+	if err := txModels.AuditLogs.Record(actor, account.ID, AuditDelete, AuditEntityAccount, account.ID, account, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TransferOwnershipTX makes the member the owner of the account
+// and the previous owner an admin of the account.
+func (m *Models) TransferOwnershipTX(account *Account, newOwnerID int64, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	oldAccount := *account
+	account.OwnerID = newOwnerID
+
+	if err := txModels.Accounts.Update(account); err != nil {
+		return err
+	}
+
+	if err := txModels.Accounts.UpdateRole(account.ID, newOwnerID, RoleOwner); err != nil {
+		return err
+	}
+
+	if err := txModels.Accounts.UpdateRole(account.ID, oldAccount.OwnerID, RoleAdmin); err != nil {
+		return err
+	}
+
+	if err := txModels.AuditLogs.Record(actor, account.ID, AuditUpdate, AuditEntityAccount, account.ID, &oldAccount, account); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Models) AddMemberTX(accountID int64, userID int64, role string, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Accounts.AddUser(userID, accountID, role); err != nil {
+		return err
+	}
+
+	after := &memberAudit{UserID: userID, Role: role}
+
+	if err := txModels.AuditLogs.Record(actor, accountID, AuditCreate, AuditEntityMember, userID, nil, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Models) UpdateMemberRoleTX(accountID int64, member *Member, role string, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Accounts.UpdateRole(accountID, member.ID, role); err != nil {
+		return err
+	}
+
+	before := &memberAudit{UserID: member.ID, Role: member.Role}
+	after := &memberAudit{UserID: member.ID, Role: role}
+
+	if err := txModels.AuditLogs.Record(actor, accountID, AuditUpdate, AuditEntityMember, member.ID, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Models) RemoveMemberTX(accountID int64, member *Member, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Accounts.RemoveUser(member.ID, accountID); err != nil {
+		return err
+	}
+
+	before := &memberAudit{UserID: member.ID, Role: member.Role}
+
+	if err := txModels.AuditLogs.Record(actor, accountID, AuditDelete, AuditEntityMember, member.ID, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RestoreAccountTX takes the account out of the trash. The totals and the statistics
+// of the account are kept while it is in the trash, so they are not recalculated.
+func (m *Models) RestoreAccountTX(account *Account, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Accounts.Restore(account.ID); err != nil {
+		return err
+	}
+
+	account.DeletedAt = nil
+
+	if err := txModels.AuditLogs.Record(actor, account.ID, AuditRestore, AuditEntityAccount, account.ID, nil, account); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
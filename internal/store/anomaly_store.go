@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	AnomalyUnusualAmount = "unusual-amount"
+	AnomalyDuplicate     = "duplicate"
+	AnomalySpendingSpike = "spending-spike"
+)
+
+var ErrAnomalyExists = errors.New("anomaly is already flagged")
+
+type Anomaly struct {
+	ID            int64      `json:"id"`
+	AccountID     int64      `json:"accountID"`
+	TransactionID int64      `json:"transactionID"`
+	Kind          string     `json:"kind"`
+	Message       string     `json:"message"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	DismissedAt   *time.Time `json:"dismissedAt,omitempty"`
+}
+
+type anomalyModel struct {
+	DB DBTX
+}
+
+func (m *anomalyModel) Insert(anomaly *Anomaly) error {
+	query := `INSERT INTO anomalies (account_id, transaction_id, kind, message)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (transaction_id, kind) DO NOTHING
+	RETURNING id, created_at`
+
+	args := []interface{}{
+		anomaly.AccountID,
+		anomaly.TransactionID,
+		anomaly.Kind,
+		anomaly.Message,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&anomaly.ID, &anomaly.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAnomalyExists
+	}
+
+	return err
+}
+
+func (m *anomalyModel) GetAllByAccountID(accountID int64, dismissed bool, filters Filters) ([]*Anomaly, error) {
+	query := fmt.Sprintf(`SELECT id, account_id, transaction_id, kind, message, created_at, dismissed_at
+	FROM anomalies
+	WHERE account_id = $1 AND (dismissed_at IS NULL OR $2)
+	ORDER BY %s %s, id ASC
+	LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, accountID, dismissed, filters.Limit, filters.offset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	anomalies := []*Anomaly{}
+
+	for rows.Next() {
+		var anomaly Anomaly
+
+		err := rows.Scan(
+			&anomaly.ID,
+			&anomaly.AccountID,
+			&anomaly.TransactionID,
+			&anomaly.Kind,
+			&anomaly.Message,
+			&anomaly.CreatedAt,
+			&anomaly.DismissedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		anomalies = append(anomalies, &anomaly)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return anomalies, nil
+}
+
+func (m *anomalyModel) Dismiss(id int64, accountID int64) error {
+	query := `UPDATE anomalies SET dismissed_at = now()
+	WHERE id = $1 AND account_id = $2 AND dismissed_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, accountID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
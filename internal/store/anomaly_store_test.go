@@ -0,0 +1,71 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAnomaly(t *testing.T) *store.Anomaly {
+	ts := createRandomTransaction(t)
+
+	anomaly := &store.Anomaly{
+		AccountID:     ts.AccountID,
+		TransactionID: ts.ID,
+		Kind:          store.AnomalyDuplicate,
+		Message:       random.String(20),
+	}
+
+	err := testModels.Anomalies.Insert(anomaly)
+	require.NoError(t, err)
+
+	require.NotZero(t, anomaly.ID)
+	require.NotZero(t, anomaly.CreatedAt)
+
+	return anomaly
+}
+
+func TestAnomalyModel_Insert(t *testing.T) {
+	anomaly := createRandomAnomaly(t)
+
+	t.Run("already flagged case for insert anomaly", func(t *testing.T) {
+		duplicate := *anomaly
+
+		err := testModels.Anomalies.Insert(&duplicate)
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrAnomalyExists)
+	})
+}
+
+func TestAnomalyModel_Dismiss(t *testing.T) {
+	anomaly := createRandomAnomaly(t)
+	filters := store.Filters{Page: 1, Limit: 20, Sort: "-id"}
+
+	anomalies, err := testModels.Anomalies.GetAllByAccountID(anomaly.AccountID, false, filters)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	require.Equal(t, anomaly.ID, anomalies[0].ID)
+	require.Nil(t, anomalies[0].DismissedAt)
+
+	t.Run("not found case for dismiss anomaly", func(t *testing.T) {
+		err := testModels.Anomalies.Dismiss(anomaly.ID, anomaly.AccountID+1)
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+
+	t.Run("success case for dismiss anomaly", func(t *testing.T) {
+		err := testModels.Anomalies.Dismiss(anomaly.ID, anomaly.AccountID)
+		require.NoError(t, err)
+
+		anomalies, err := testModels.Anomalies.GetAllByAccountID(anomaly.AccountID, false, filters)
+		require.NoError(t, err)
+		require.Empty(t, anomalies)
+
+		anomalies, err = testModels.Anomalies.GetAllByAccountID(anomaly.AccountID, true, filters)
+		require.NoError(t, err)
+		require.Len(t, anomalies, 1)
+		require.NotNil(t, anomalies[0].DismissedAt)
+	})
+}
@@ -0,0 +1,240 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APITokenPrefix marks the API tokens so that they can be told apart
+// from the access tokens and found by the secret scanners.
+const APITokenPrefix = "gxp_"
+
+const (
+	APIScopeTransactionsRead  = "transactions:read"
+	APIScopeTransactionsWrite = "transactions:write"
+	APIScopeAccountsRead      = "accounts:read"
+	APIScopeAccountsWrite     = "accounts:write"
+)
+
+// APIToken is a long-lived token the users create for their scripts and
+// integrations. It is limited to the scopes and, if any are given, to the accounts.
+type APIToken struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Plaintext  string     `json:"token,omitempty"`
+	Hash       []byte     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	AccountIDs []int64    `json:"accountIDs"`
+	Expiry     *time.Time `json:"expiry,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRestricted reports whether the token is limited to some of the accounts of the user.
+func (t *APIToken) IsRestricted() bool {
+	return len(t.AccountIDs) > 0
+}
+
+func (t *APIToken) AllowsAccount(accountID int64) bool {
+	if !t.IsRestricted() {
+		return true
+	}
+
+	for _, id := range t.AccountIDs {
+		if id == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+type apiTokenModel struct {
+	DB DBTX
+}
+
+// New generates the plaintext of the token and inserts it. The plaintext
+// is only available in the returned token and is never stored.
+func (m *apiTokenModel) New(token *APIToken) error {
+	random, err := randomString(20)
+	if err != nil {
+		return err
+	}
+
+	token.Plaintext = APITokenPrefix + strings.ToLower(random)
+	token.Prefix = token.Plaintext[:len(APITokenPrefix)+6]
+
+	hash := sha256.Sum256([]byte(token.Plaintext))
+	token.Hash = hash[:]
+
+	if token.AccountIDs == nil {
+		token.AccountIDs = []int64{}
+	}
+
+	query := `INSERT INTO api_tokens (user_id, name, prefix, hash, scopes, account_ids, expiry)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, created_at`
+
+	args := []interface{}{
+		token.UserID,
+		token.Name,
+		token.Prefix,
+		token.Hash,
+		pq.Array(token.Scopes),
+		pq.Array(token.AccountIDs),
+		token.Expiry,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&token.ID, &token.CreatedAt)
+}
+
+// GetByToken returns the token if it is neither revoked nor expired.
+func (m *apiTokenModel) GetByToken(tokenPlaintext string) (*APIToken, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `SELECT id, user_id, name, prefix, scopes, account_ids, expiry, created_at, last_used_at
+	FROM api_tokens
+	WHERE hash = $1 AND revoked_at IS NULL AND (expiry IS NULL OR expiry > $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var token APIToken
+
+	err := m.DB.QueryRowContext(ctx, query, tokenHash[:], time.Now()).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Prefix,
+		pq.Array(&token.Scopes),
+		pq.Array(&token.AccountIDs),
+		&token.Expiry,
+		&token.CreatedAt,
+		&token.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// GetAllByUserID returns the tokens of the user which are not revoked from the newest one.
+func (m *apiTokenModel) GetAllByUserID(userID int64) ([]*APIToken, error) {
+	query := `SELECT id, user_id, name, prefix, scopes, account_ids, expiry, created_at, last_used_at
+	FROM api_tokens
+	WHERE user_id = $1 AND revoked_at IS NULL
+	ORDER BY id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*APIToken{}
+
+	for rows.Next() {
+		var token APIToken
+
+		err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&token.Prefix,
+			pq.Array(&token.Scopes),
+			pq.Array(&token.AccountIDs),
+			&token.Expiry,
+			&token.CreatedAt,
+			&token.LastUsedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, &token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Touch updates the last used time of the token at most once in the interval.
+func (m *apiTokenModel) Touch(id int64, interval time.Duration) error {
+	query := `UPDATE api_tokens SET last_used_at = now()
+	WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, id, time.Now().Add(-interval))
+
+	return err
+}
+
+// Revoke revokes the token of the user. It returns ErrRecordNotFound
+// if the token does not exist or is already revoked.
+func (m *apiTokenModel) Revoke(id int64, userID int64) error {
+	query := `UPDATE api_tokens SET revoked_at = now()
+	WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes all the tokens of the user, such as
+// when the account is recovered from someone who took it over.
+func (m *apiTokenModel) RevokeAllForUser(userID int64) error {
+	query := `UPDATE api_tokens SET revoked_at = now()
+	WHERE user_id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+	return err
+}
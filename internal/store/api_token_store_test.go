@@ -0,0 +1,109 @@
+package store_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIToken(t *testing.T, userID int64, expiry *time.Time) *store.APIToken {
+	token := &store.APIToken{
+		UserID: userID,
+		Name:   "script",
+		Scopes: []string{store.APIScopeTransactionsRead},
+		Expiry: expiry,
+	}
+
+	err := testModels.APITokens.New(token)
+	require.NoError(t, err)
+	require.NotZero(t, token.ID)
+	require.True(t, strings.HasPrefix(token.Plaintext, store.APITokenPrefix))
+	require.True(t, strings.HasPrefix(token.Plaintext, token.Prefix))
+
+	return token
+}
+
+func TestAPITokenModel_GetByToken(t *testing.T) {
+	user := createRandomUser(t)
+	token := createRandomAPIToken(t, user.ID, nil)
+
+	got, err := testModels.APITokens.GetByToken(token.Plaintext)
+	require.NoError(t, err)
+	require.Equal(t, token.ID, got.ID)
+	require.Equal(t, user.ID, got.UserID)
+	require.Empty(t, got.Plaintext)
+	require.True(t, got.HasScope(store.APIScopeTransactionsRead))
+	require.False(t, got.HasScope(store.APIScopeTransactionsWrite))
+	require.False(t, got.IsRestricted())
+
+	t.Run("expired token case for get by token", func(t *testing.T) {
+		expiry := time.Now().Add(time.Second)
+		expired := createRandomAPIToken(t, user.ID, &expiry)
+
+		time.Sleep(2 * time.Second)
+
+		_, err := testModels.APITokens.GetByToken(expired.Plaintext)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+
+	t.Run("revoked token case for get by token", func(t *testing.T) {
+		err := testModels.APITokens.Revoke(token.ID, user.ID)
+		require.NoError(t, err)
+
+		_, err = testModels.APITokens.GetByToken(token.Plaintext)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+		err = testModels.APITokens.Revoke(token.ID, user.ID)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
+
+func TestAPITokenModel_Touch(t *testing.T) {
+	user := createRandomUser(t)
+	token := createRandomAPIToken(t, user.ID, nil)
+
+	err := testModels.APITokens.Touch(token.ID, time.Minute)
+	require.NoError(t, err)
+
+	tokens, err := testModels.APITokens.GetAllByUserID(user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.NotNil(t, tokens[0].LastUsedAt)
+	require.WithinDuration(t, time.Now(), *tokens[0].LastUsedAt, 2*time.Second)
+}
+
+func TestAPITokenModel_RevokeAllForUser(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+
+	first := createRandomAPIToken(t, user.ID, nil)
+	second := createRandomAPIToken(t, user.ID, nil)
+	otherToken := createRandomAPIToken(t, other.ID, nil)
+
+	err := testModels.APITokens.RevokeAllForUser(user.ID)
+	require.NoError(t, err)
+
+	for _, token := range []*store.APIToken{first, second} {
+		_, err := testModels.APITokens.GetByToken(token.Plaintext)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	}
+
+	tokens, err := testModels.APITokens.GetAllByUserID(user.ID)
+	require.NoError(t, err)
+	require.Empty(t, tokens)
+
+	// The tokens of the other users are not revoked.
+	_, err = testModels.APITokens.GetByToken(otherToken.Plaintext)
+	require.NoError(t, err)
+}
+
+func TestAPIToken_AllowsAccount(t *testing.T) {
+	token := &store.APIToken{AccountIDs: []int64{1, 2}}
+
+	require.True(t, token.IsRestricted())
+	require.True(t, token.AllowsAccount(2))
+	require.False(t, token.AllowsAccount(3))
+}
@@ -0,0 +1,225 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+const (
+	AuditEntityAccount     = "account"
+	AuditEntityTransaction = "transaction"
+	AuditEntityMember      = "member"
+)
+
+// auditIgnoredFields are not recorded in the audit log since they either
+// change on every write or are embedded records of other entities.
+var auditIgnoredFields = map[string]bool{
+	"version": true,
+	"user":    true,
+	"account": true,
+}
+
+// Actor is the user who makes a change and the address the request came from.
+type Actor struct {
+	UserID int64
+	IP     string
+}
+
+// AuditLog is a change made to an account or to one of its records. Before and
+// After only hold the fields which are changed.
+type AuditLog struct {
+	ID        int64           `json:"id"`
+	AccountID int64           `json:"accountID"`
+	ActorID   int64           `json:"actorID"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int64           `json:"entityID"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IP        string          `json:"ip"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type auditModel struct {
+	DB DBTX
+}
+
+// Record inserts a log for the change of the entity. Before is nil for
+// the created entities and after is nil for the deleted ones.
+func (m *auditModel) Record(actor Actor, accountID int64, action string, entity string, entityID int64, before interface{}, after interface{}) error {
+	beforeJSON, afterJSON, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	log := &AuditLog{
+		AccountID: accountID,
+		ActorID:   actor.UserID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Before:    beforeJSON,
+		After:     afterJSON,
+		IP:        actor.IP,
+	}
+
+	return m.Insert(log)
+}
+
+func (m *auditModel) Insert(log *AuditLog) error {
+	query := `INSERT INTO audit_logs (account_id, actor_id, action, entity, entity_id, before, after, ip)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at`
+
+	args := []interface{}{
+		log.AccountID,
+		log.ActorID,
+		log.Action,
+		log.Entity,
+		log.EntityID,
+		nullableJSON(log.Before),
+		nullableJSON(log.After),
+		log.IP,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&log.ID, &log.CreatedAt)
+}
+
+// GetAllByAccountID returns the logs of the account from the newest to the oldest.
+// The actor and the entity are only filtered when they are given.
+func (m *auditModel) GetAllByAccountID(accountID int64, actorID int64, entity string, filters Filters) ([]*AuditLog, error) {
+	query := fmt.Sprintf(`SELECT id, account_id, actor_id, action, entity, entity_id, before, after, ip, created_at
+	FROM audit_logs
+	WHERE account_id = $1 AND (actor_id = $2 OR $2 = 0) AND (entity = $3 OR $3 = '')
+	ORDER BY %s %s
+	LIMIT $4 OFFSET $5`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, accountID, actorID, entity, filters.Limit, filters.offset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []*AuditLog{}
+
+	for rows.Next() {
+		var log AuditLog
+		var before, after []byte
+
+		err := rows.Scan(
+			&log.ID,
+			&log.AccountID,
+			&log.ActorID,
+			&log.Action,
+			&log.Entity,
+			&log.EntityID,
+			&before,
+			&after,
+			&log.IP,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Before = before
+		log.After = after
+
+		logs = append(logs, &log)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+// diff encodes the fields of before and after which are not equal.
+// When one of them is nil, all the fields of the other one are encoded.
+func diff(before interface{}, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && reflect.DeepEqual(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := encodeFields(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterJSON, err := encodeFields(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
+
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(js, &fields); err != nil {
+		return nil, err
+	}
+
+	for key := range auditIgnoredFields {
+		delete(fields, key)
+	}
+
+	return fields, nil
+}
+
+func encodeFields(fields map[string]interface{}) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(fields)
+}
+
+func nullableJSON(js json.RawMessage) interface{} {
+	if js == nil {
+		return nil
+	}
+
+	return []byte(js)
+}
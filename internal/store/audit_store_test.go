@@ -0,0 +1,56 @@
+package store_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditModel_Record(t *testing.T) {
+	oldTS, account, stat := createRandomTX(t)
+	actor := store.Actor{UserID: oldTS.UserID, IP: "127.0.0.1"}
+
+	newTS := *oldTS
+	newTS.Amount = oldTS.Amount + 10
+
+	err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat, actor)
+	require.NoError(t, err)
+
+	filters := store.Filters{Page: 1, Limit: 20, Sort: "-id"}
+
+	logs, err := testModels.AuditLogs.GetAllByAccountID(account.ID, 0, "", filters)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+
+	updated := logs[0]
+	require.Equal(t, store.AuditUpdate, updated.Action)
+	require.Equal(t, store.AuditEntityTransaction, updated.Entity)
+	require.Equal(t, newTS.ID, updated.EntityID)
+	require.Equal(t, actor.UserID, updated.ActorID)
+	require.Equal(t, actor.IP, updated.IP)
+
+	var before, after map[string]interface{}
+	require.NoError(t, json.Unmarshal(updated.Before, &before))
+	require.NoError(t, json.Unmarshal(updated.After, &after))
+
+	// Only the changed fields are recorded.
+	require.Equal(t, map[string]interface{}{"amount": oldTS.Amount}, before)
+	require.Equal(t, map[string]interface{}{"amount": newTS.Amount}, after)
+
+	created := logs[1]
+	require.Equal(t, store.AuditCreate, created.Action)
+	require.Nil(t, created.Before)
+	require.NotNil(t, created.After)
+
+	t.Run("filtered case for get audit logs", func(t *testing.T) {
+		logs, err := testModels.AuditLogs.GetAllByAccountID(account.ID, actor.UserID+1, "", filters)
+		require.NoError(t, err)
+		require.Empty(t, logs)
+
+		logs, err = testModels.AuditLogs.GetAllByAccountID(account.ID, 0, store.AuditEntityAccount, filters)
+		require.NoError(t, err)
+		require.Empty(t, logs)
+	})
+}
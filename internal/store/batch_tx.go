@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation is a change of a transaction in a batch. Old is the current
+// version of the transaction for the update and the delete operations.
+type BatchOperation struct {
+	Action      string
+	Transaction *Transaction
+	Old         *Transaction
+}
+
+type batchDelta struct {
+	income  float64
+	expense float64
+}
+
+func (d *batchDelta) add(ts *Transaction, sign float64) {
+	if ts.Type == "income" {
+		d.income += sign * ts.Amount
+	} else {
+		d.expense += sign * ts.Amount
+	}
+}
+
+type batchDayKey struct {
+	accountID int64
+	date      string
+}
+
+type batchDay struct {
+	accountID int64
+	date      time.Time
+	batchDelta
+}
+
+// BatchTX applies the operations in the given order. The totals of the accounts and
+// the daily statistics are updated once for all of the operations at the end.
+func (m *Models) BatchTX(operations []*BatchOperation, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	accounts := make(map[int64]*batchDelta)
+	days := make(map[batchDayKey]*batchDay)
+
+	apply := func(ts *Transaction, sign float64) {
+		if _, ok := accounts[ts.AccountID]; !ok {
+			accounts[ts.AccountID] = &batchDelta{}
+		}
+		accounts[ts.AccountID].add(ts, sign)
+
+		// The paydays are normalized to the UTC dates the statistics are stored with, so
+		// that the old and the new versions with different offsets share the same day.
+		date := ts.Payday.UTC().Truncate(24 * time.Hour)
+
+		key := batchDayKey{accountID: ts.AccountID, date: date.Format("2006-01-02")}
+		if _, ok := days[key]; !ok {
+			days[key] = &batchDay{accountID: ts.AccountID, date: date}
+		}
+		days[key].add(ts, sign)
+	}
+
+	for _, op := range operations {
+		switch op.Action {
+		case BatchCreate:
+			if err := txModels.Transactions.Insert(op.Transaction); err != nil {
+				return err
+			}
+
+			if err := txModels.AuditLogs.Record(actor, op.Transaction.AccountID, AuditCreate, AuditEntityTransaction, op.Transaction.ID, nil, op.Transaction); err != nil {
+				return err
+			}
+
+			apply(op.Transaction, 1)
+		case BatchUpdate:
+			if err := txModels.Transactions.Update(op.Transaction); err != nil {
+				return err
+			}
+
+			if err := txModels.Revisions.Insert(NewRevision(op.Old, actor.UserID)); err != nil {
+				return err
+			}
+
+			if err := txModels.AuditLogs.Record(actor, op.Transaction.AccountID, AuditUpdate, AuditEntityTransaction, op.Transaction.ID, op.Old, op.Transaction); err != nil {
+				return err
+			}
+
+			apply(op.Old, -1)
+			apply(op.Transaction, 1)
+		case BatchDelete:
+			if err := txModels.Transactions.Delete(op.Old.ID, op.Old.UserID); err != nil {
+				return err
+			}
+
+			if err := txModels.AuditLogs.Record(actor, op.Old.AccountID, AuditDelete, AuditEntityTransaction, op.Old.ID, op.Old, nil); err != nil {
+				return err
+			}
+
+			apply(op.Old, -1)
+		}
+	}
+
+	// The rows are updated in the same order by every batch
+	// so that concurrent batches do not deadlock.
+	accountIDs := make([]int64, 0, len(accounts))
+	for id := range accounts {
+		accountIDs = append(accountIDs, id)
+	}
+	sort.Slice(accountIDs, func(i, j int) bool { return accountIDs[i] < accountIDs[j] })
+
+	for _, id := range accountIDs {
+		account, err := txModels.Accounts.Get(id)
+		if err != nil {
+			return err
+		}
+
+		account.TotalIncome += accounts[id].income
+		account.TotalExpense += accounts[id].expense
+
+		if err := txModels.Accounts.Update(account); err != nil {
+			return err
+		}
+	}
+
+	keys := make([]batchDayKey, 0, len(days))
+	for key := range days {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].accountID != keys[j].accountID {
+			return keys[i].accountID < keys[j].accountID
+		}
+		return keys[i].date < keys[j].date
+	})
+
+	for _, key := range keys {
+		day := days[key]
+
+		stat, err := txModels.Statistics.GetByDate(day.accountID, day.date)
+		if err != nil {
+			if !errors.Is(err, ErrRecordNotFound) {
+				return err
+			}
+
+			stat = &Statistic{
+				AccountID: day.accountID,
+				Date:      day.date,
+				Earning:   day.income,
+				Spending:  day.expense,
+			}
+
+			if err := txModels.Statistics.Insert(stat); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		stat.Earning += day.income
+		stat.Spending += day.expense
+
+		if err := txModels.Statistics.Update(stat); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,162 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModels_BatchTX(t *testing.T) {
+	updated, account, _ := createRandomTX(t)
+	deleted := &store.Transaction{
+		UserID:    updated.UserID,
+		AccountID: account.ID,
+		Type:      "expense",
+		Title:     random.String(12),
+		Amount:    float64(random.Int(1, 300)),
+		Payday:    updated.Payday,
+	}
+
+	stat, err := testModels.Statistics.GetByDate(account.ID, updated.Payday)
+	require.NoError(t, err)
+
+	err = testModels.CreateTransactionTX(deleted, account, stat, store.Actor{UserID: updated.UserID})
+	require.NoError(t, err)
+
+	before, err := testModels.Accounts.Get(account.ID)
+	require.NoError(t, err)
+
+	statBefore, err := testModels.Statistics.GetByDate(account.ID, updated.Payday)
+	require.NoError(t, err)
+
+	created := &store.Transaction{
+		UserID:    updated.UserID,
+		AccountID: account.ID,
+		Type:      "income",
+		Title:     random.String(12),
+		Amount:    50,
+		Payday:    updated.Payday,
+	}
+
+	newTS := *updated
+	newTS.User = nil
+	newTS.Account = nil
+	newTS.Amount = updated.Amount + 10
+
+	operations := []*store.BatchOperation{
+		{Action: store.BatchCreate, Transaction: created},
+		{Action: store.BatchUpdate, Transaction: &newTS, Old: updated},
+		{Action: store.BatchDelete, Transaction: deleted, Old: deleted},
+	}
+
+	err = testModels.BatchTX(operations, store.Actor{UserID: updated.UserID})
+	require.NoError(t, err)
+	require.NotZero(t, created.ID)
+
+	after, err := testModels.Accounts.Get(account.ID)
+	require.NoError(t, err)
+
+	statAfter, err := testModels.Statistics.GetByDate(account.ID, updated.Payday)
+	require.NoError(t, err)
+
+	expectedIncome := before.TotalIncome + 50
+	expectedExpense := before.TotalExpense - deleted.Amount
+
+	if updated.Type == "income" {
+		expectedIncome += 10
+	} else {
+		expectedExpense += 10
+	}
+
+	require.InDelta(t, expectedIncome, after.TotalIncome, 0.001)
+	require.InDelta(t, expectedExpense, after.TotalExpense, 0.001)
+	require.InDelta(t, statBefore.Earning+expectedIncome-before.TotalIncome, statAfter.Earning, 0.001)
+	require.InDelta(t, statBefore.Spending+expectedExpense-before.TotalExpense, statAfter.Spending, 0.001)
+
+	_, err = testModels.Transactions.Get(deleted.ID)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	t.Run("conflict case for batch", func(t *testing.T) {
+		another := &store.Transaction{
+			UserID:    updated.UserID,
+			AccountID: account.ID,
+			Type:      "income",
+			Title:     random.String(12),
+			Amount:    20,
+			Payday:    updated.Payday,
+		}
+
+		stale := *updated
+		stale.Title = random.String(12)
+
+		// The update of the stale version fails, so the create is rolled back as well.
+		operations := []*store.BatchOperation{
+			{Action: store.BatchCreate, Transaction: another},
+			{Action: store.BatchUpdate, Transaction: &stale, Old: updated},
+		}
+
+		err := testModels.BatchTX(operations, store.Actor{UserID: updated.UserID})
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrEditConflict)
+
+		_, err = testModels.Transactions.Get(another.ID)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+		unchanged, err := testModels.Accounts.Get(account.ID)
+		require.NoError(t, err)
+		require.Equal(t, after.TotalIncome, unchanged.TotalIncome)
+	})
+}
+
+func TestModels_BatchTX_Offsets(t *testing.T) {
+	updated, account, _ := createRandomTX(t)
+
+	// The paydays are on the same UTC day, but on different days in their own offsets.
+	day := updated.Payday.UTC().Truncate(24*time.Hour).AddDate(0, 0, 2)
+	late := day.Add(23 * time.Hour).In(time.FixedZone("UTC+3", 3*60*60))
+	early := day.Add(10 * time.Hour).In(time.FixedZone("UTC-5", -5*60*60))
+
+	first := &store.Transaction{
+		UserID:    updated.UserID,
+		AccountID: account.ID,
+		Type:      "income",
+		Title:     random.String(12),
+		Amount:    30,
+		Payday:    late,
+	}
+
+	second := &store.Transaction{
+		UserID:    updated.UserID,
+		AccountID: account.ID,
+		Type:      "income",
+		Title:     random.String(12),
+		Amount:    20,
+		Payday:    early,
+	}
+
+	// The old version is read in UTC and the new one has the offset of the client.
+	newTS := *updated
+	newTS.User = nil
+	newTS.Account = nil
+	newTS.Payday = updated.Payday.In(time.FixedZone("UTC-8", -8*60*60))
+
+	operations := []*store.BatchOperation{
+		{Action: store.BatchCreate, Transaction: first},
+		{Action: store.BatchCreate, Transaction: second},
+		{Action: store.BatchUpdate, Transaction: &newTS, Old: updated},
+	}
+
+	err := testModels.BatchTX(operations, store.Actor{UserID: updated.UserID})
+	require.NoError(t, err)
+
+	stat, err := testModels.Statistics.GetByDate(account.ID, day)
+	require.NoError(t, err)
+	require.InDelta(t, 50, stat.Earning, 0.001)
+
+	statUpdated, err := testModels.Statistics.GetByDate(account.ID, updated.Payday.UTC().Truncate(24*time.Hour))
+	require.NoError(t, err)
+	require.InDelta(t, updated.Amount, statUpdated.Earning+statUpdated.Spending, 0.001)
+}
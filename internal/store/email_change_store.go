@@ -0,0 +1,104 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// EmailChange is a change of the email of the user. It is pending until the new email
+// is confirmed and can be cancelled from the old email until its cancel token expires.
+type EmailChange struct {
+	UserID      int64      `json:"-"`
+	OldEmail    string     `json:"-"`
+	NewEmail    string     `json:"newEmail"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ConfirmedAt *time.Time `json:"confirmedAt,omitempty"`
+}
+
+func (c *EmailChange) IsConfirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+type emailChangeModel struct {
+	DB DBTX
+}
+
+// Upsert replaces the change of the user with the new one.
+func (m *emailChangeModel) Upsert(change *EmailChange) error {
+	query := `INSERT INTO email_changes (user_id, old_email, new_email)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE
+	SET old_email = EXCLUDED.old_email, new_email = EXCLUDED.new_email, created_at = now(), confirmed_at = NULL
+	RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	change.ConfirmedAt = nil
+
+	return m.DB.QueryRowContext(ctx, query, change.UserID, change.OldEmail, change.NewEmail).Scan(&change.CreatedAt)
+}
+
+func (m *emailChangeModel) Get(userID int64) (*EmailChange, error) {
+	query := `SELECT user_id, old_email, new_email, created_at, confirmed_at
+	FROM email_changes
+	WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var change EmailChange
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(
+		&change.UserID,
+		&change.OldEmail,
+		&change.NewEmail,
+		&change.CreatedAt,
+		&change.ConfirmedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func (m *emailChangeModel) Confirm(userID int64) error {
+	query := `UPDATE email_changes SET confirmed_at = now()
+	WHERE user_id = $1 AND confirmed_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m *emailChangeModel) Delete(userID int64) error {
+	query := `DELETE FROM email_changes WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+
+	return err
+}
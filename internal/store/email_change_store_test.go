@@ -0,0 +1,98 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func requestRandomEmailChange(t *testing.T, user store.User) (*store.EmailChange, *store.Token, *store.Token) {
+	change := &store.EmailChange{UserID: user.ID, OldEmail: user.Email, NewEmail: random.Email()}
+
+	confirmToken, cancelToken, err := testModels.RequestEmailChangeTX(change, time.Hour, 24*time.Hour)
+	require.NoError(t, err)
+	require.NotEmpty(t, confirmToken.Plaintext)
+	require.NotEmpty(t, cancelToken.Plaintext)
+	require.NotZero(t, change.CreatedAt)
+
+	return change, confirmToken, cancelToken
+}
+
+func TestModels_ConfirmEmailChangeTX(t *testing.T) {
+	user := createRandomUser(t)
+	change, confirmToken, _ := requestRandomEmailChange(t, user)
+
+	got, err := testModels.Users.Get(user.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, got.Email)
+
+	updated, _, err := testModels.ConfirmEmailChangeTX(confirmToken.Plaintext)
+	require.NoError(t, err)
+	require.Equal(t, change.NewEmail, updated.Email)
+
+	_, _, err = testModels.ConfirmEmailChangeTX(confirmToken.Plaintext)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	confirmed, err := testModels.EmailChanges.Get(user.ID)
+	require.NoError(t, err)
+	require.True(t, confirmed.IsConfirmed())
+
+	t.Run("taken email case for confirm email change", func(t *testing.T) {
+		user := createRandomUser(t)
+		other := createRandomUser(t)
+
+		change := &store.EmailChange{UserID: user.ID, OldEmail: user.Email, NewEmail: other.Email}
+
+		confirmToken, _, err := testModels.RequestEmailChangeTX(change, time.Hour, 24*time.Hour)
+		require.NoError(t, err)
+
+		_, _, err = testModels.ConfirmEmailChangeTX(confirmToken.Plaintext)
+		require.ErrorIs(t, err, store.ErrDuplicateEmail)
+	})
+
+	t.Run("replaced change case for confirm email change", func(t *testing.T) {
+		user := createRandomUser(t)
+
+		_, oldToken, _ := requestRandomEmailChange(t, user)
+		requestRandomEmailChange(t, user)
+
+		_, _, err := testModels.ConfirmEmailChangeTX(oldToken.Plaintext)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
+
+func TestModels_CancelEmailChangeTX(t *testing.T) {
+	t.Run("pending change case for cancel email change", func(t *testing.T) {
+		user := createRandomUser(t)
+		_, confirmToken, cancelToken := requestRandomEmailChange(t, user)
+
+		got, _, err := testModels.CancelEmailChangeTX(cancelToken.Plaintext)
+		require.NoError(t, err)
+		require.Equal(t, user.Email, got.Email)
+
+		_, _, err = testModels.ConfirmEmailChangeTX(confirmToken.Plaintext)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+		_, err = testModels.EmailChanges.Get(user.ID)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+
+	t.Run("confirmed change case for cancel email change", func(t *testing.T) {
+		user := createRandomUser(t)
+		_, confirmToken, cancelToken := requestRandomEmailChange(t, user)
+
+		_, _, err := testModels.ConfirmEmailChangeTX(confirmToken.Plaintext)
+		require.NoError(t, err)
+
+		got, change, err := testModels.CancelEmailChangeTX(cancelToken.Plaintext)
+		require.NoError(t, err)
+		require.True(t, change.IsConfirmed())
+		require.Equal(t, user.Email, got.Email)
+
+		_, _, err = testModels.CancelEmailChangeTX(cancelToken.Plaintext)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
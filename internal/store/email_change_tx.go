@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// RequestEmailChangeTX replaces the change of the user and returns the token to confirm
+// the new email and the token to cancel the change from the old email.
+func (m *Models) RequestEmailChangeTX(change *EmailChange, confirmTTL time.Duration, cancelTTL time.Duration) (*Token, *Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.EmailChanges.Upsert(change); err != nil {
+		return nil, nil, err
+	}
+
+	for _, scope := range []string{ScopeEmailChange, ScopeEmailChangeCancel} {
+		if err := txModels.Tokens.DeleteAllForUser(scope, change.UserID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	confirmToken, err := txModels.Tokens.New(change.UserID, confirmTTL, ScopeEmailChange)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cancelToken, err := txModels.Tokens.New(change.UserID, cancelTTL, ScopeEmailChangeCancel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return confirmToken, cancelToken, nil
+}
+
+// ConfirmEmailChangeTX uses the confirm token and changes the email of its user to
+// the new email. The uniqueness of the email is checked again by the update.
+func (m *Models) ConfirmEmailChangeTX(tokenPlaintext string) (*User, *EmailChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	userID, err := txModels.Tokens.Use(ScopeEmailChange, tokenPlaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	change, err := txModels.EmailChanges.Get(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if change.IsConfirmed() {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	user, err := txModels.Users.Get(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user.Email = change.NewEmail
+
+	if err := txModels.Users.Update(user); err != nil {
+		return nil, nil, err
+	}
+
+	if err := txModels.EmailChanges.Confirm(userID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return user, change, nil
+}
+
+// CancelEmailChangeTX uses the cancel token and cancels the change of its user.
+// The old email is restored if the change is already confirmed.
+func (m *Models) CancelEmailChangeTX(tokenPlaintext string) (*User, *EmailChange, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	userID, err := txModels.Tokens.Use(ScopeEmailChangeCancel, tokenPlaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	change, err := txModels.EmailChanges.Get(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := txModels.Users.Get(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if change.IsConfirmed() {
+		user.Email = change.OldEmail
+
+		if err := txModels.Users.Update(user); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := txModels.EmailChanges.Delete(userID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := txModels.Tokens.DeleteAllForUser(ScopeEmailChange, userID); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return user, change, nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BaseCurrency is the currency every exchange rate is quoted against.
+const BaseCurrency = "USD"
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Rates maps a currency code to the amount of that currency
+// which equals one unit of the BaseCurrency.
+type Rates map[string]float64
+
+// Convert converts the amount from one currency to another over the BaseCurrency.
+func (r Rates) Convert(amount float64, from string, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	fromRate, err := r.rate(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := r.rate(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount / fromRate * toRate, nil
+}
+
+func (r Rates) rate(currency string) (float64, error) {
+	if currency == BaseCurrency {
+		return 1, nil
+	}
+
+	rate, ok := r[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+
+	return rate, nil
+}
+
+type exchangeRateModel struct {
+	DB DBTX
+}
+
+func (m *exchangeRateModel) Upsert(currency string, rate float64) error {
+	query := `INSERT INTO exchange_rates (currency, rate)
+	VALUES ($1, $2)
+	ON CONFLICT (currency) DO UPDATE SET rate=EXCLUDED.rate, updated_at=now()`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, currency, rate)
+
+	return err
+}
+
+func (m *exchangeRateModel) GetAll() (Rates, error) {
+	query := `SELECT currency, rate
+	FROM exchange_rates`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := Rates{}
+
+	for rows.Next() {
+		var currency string
+		var rate float64
+
+		if err := rows.Scan(&currency, &rate); err != nil {
+			return nil, err
+		}
+
+		rates[currency] = rate
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,56 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeRateModel_GetAll(t *testing.T) {
+	err := testModels.Rates.Upsert("EUR", 0.5)
+	require.NoError(t, err)
+
+	rates, err := testModels.Rates.GetAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, rates)
+
+	require.Equal(t, 0.5, rates["EUR"])
+}
+
+func TestUpsertRatesTX(t *testing.T) {
+	err := testModels.UpsertRatesTX(store.Rates{"GBP": 0.75, "JPY": 150})
+	require.NoError(t, err)
+
+	rates, err := testModels.Rates.GetAll()
+	require.NoError(t, err)
+
+	require.Equal(t, 0.75, rates["GBP"])
+	require.Equal(t, float64(150), rates["JPY"])
+}
+
+func TestRates_Convert(t *testing.T) {
+	rates := store.Rates{"EUR": 0.5, "TRY": 10}
+
+	t.Run("same currency case for convert", func(t *testing.T) {
+		amount, err := rates.Convert(42, "XXX", "XXX")
+		require.NoError(t, err)
+		require.Equal(t, float64(42), amount)
+	})
+
+	t.Run("success case for convert", func(t *testing.T) {
+		amount, err := rates.Convert(10, "EUR", store.BaseCurrency)
+		require.NoError(t, err)
+		require.Equal(t, float64(20), amount)
+
+		amount, err = rates.Convert(10, "EUR", "TRY")
+		require.NoError(t, err)
+		require.Equal(t, float64(200), amount)
+	})
+
+	t.Run("unknown currency case for convert", func(t *testing.T) {
+		_, err := rates.Convert(10, "GBP", "EUR")
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrUnknownCurrency)
+	})
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// UpsertRatesTX stores all the rates at once, so that the reports never
+// convert with a mix of the old and the new rates.
+func (m *Models) UpsertRatesTX(rates Rates) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	for currency, rate := range rates {
+		if err := txModels.Rates.Upsert(currency, rate); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
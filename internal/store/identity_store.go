@@ -0,0 +1,146 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrDuplicateIdentity = errors.New("duplicate identity")
+	ErrProviderLinked    = errors.New("provider is already linked")
+)
+
+// Identity is an account of the user on an OpenID Connect provider.
+// The subject is the ID of the user on the provider.
+type Identity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type identityModel struct {
+	DB DBTX
+}
+
+func (m *identityModel) Insert(identity *Identity) error {
+	query := `INSERT INTO identities (user_id, provider, subject, email)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at`
+
+	args := []interface{}{identity.UserID, identity.Provider, identity.Subject, identity.Email}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		switch {
+		case err.Error() == `pq: duplicate key value violates unique constraint "identities_provider_subject_key"`:
+			return ErrDuplicateIdentity
+		case err.Error() == `pq: duplicate key value violates unique constraint "identities_user_id_provider_key"`:
+			return ErrProviderLinked
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *identityModel) GetBySubject(provider string, subject string) (*Identity, error) {
+	query := `SELECT id, user_id, provider, subject, email, created_at
+	FROM identities
+	WHERE provider = $1 AND subject = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var identity Identity
+
+	err := m.DB.QueryRowContext(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (m *identityModel) GetAllByUserID(userID int64) ([]*Identity, error) {
+	query := `SELECT id, user_id, provider, subject, email, created_at
+	FROM identities
+	WHERE user_id = $1
+	ORDER BY id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*Identity{}
+
+	for rows.Next() {
+		var identity Identity
+
+		err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, &identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (m *identityModel) Delete(id int64, userID int64) error {
+	query := `DELETE FROM identities WHERE id = $1 AND user_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
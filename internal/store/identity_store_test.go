@@ -0,0 +1,98 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdentity(t *testing.T, userID int64, provider string) *store.Identity {
+	identity := &store.Identity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  random.String(20),
+		Email:    random.Email(),
+	}
+
+	err := testModels.Identities.Insert(identity)
+	require.NoError(t, err)
+	require.NotZero(t, identity.ID)
+	require.NotZero(t, identity.CreatedAt)
+
+	return identity
+}
+
+func TestIdentityModel_Insert(t *testing.T) {
+	user := createRandomUser(t)
+	identity := createRandomIdentity(t, user.ID, "google")
+
+	t.Run("duplicate subject case for insert", func(t *testing.T) {
+		other := createRandomUser(t)
+
+		err := testModels.Identities.Insert(&store.Identity{UserID: other.ID, Provider: "google", Subject: identity.Subject})
+		require.ErrorIs(t, err, store.ErrDuplicateIdentity)
+	})
+
+	t.Run("linked provider case for insert", func(t *testing.T) {
+		err := testModels.Identities.Insert(&store.Identity{UserID: user.ID, Provider: "google", Subject: random.String(20)})
+		require.ErrorIs(t, err, store.ErrProviderLinked)
+	})
+}
+
+func TestIdentityModel_GetBySubject(t *testing.T) {
+	user := createRandomUser(t)
+	identity := createRandomIdentity(t, user.ID, "google")
+
+	got, err := testModels.Identities.GetBySubject("google", identity.Subject)
+	require.NoError(t, err)
+	require.Equal(t, identity.ID, got.ID)
+	require.Equal(t, user.ID, got.UserID)
+	require.Equal(t, identity.Email, got.Email)
+
+	_, err = testModels.Identities.GetBySubject("other", identity.Subject)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestIdentityModel_Delete(t *testing.T) {
+	user := createRandomUser(t)
+	identity := createRandomIdentity(t, user.ID, "google")
+	createRandomIdentity(t, user.ID, "company")
+
+	other := createRandomUser(t)
+
+	err := testModels.Identities.Delete(identity.ID, other.ID)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	err = testModels.Identities.Delete(identity.ID, user.ID)
+	require.NoError(t, err)
+
+	identities, err := testModels.Identities.GetAllByUserID(user.ID)
+	require.NoError(t, err)
+	require.Len(t, identities, 1)
+	require.Equal(t, "company", identities[0].Provider)
+}
+
+func TestModels_CreateUserWithIdentityTX(t *testing.T) {
+	user := &store.User{Name: random.Name(), Email: random.Email(), IsActivated: true}
+	require.NoError(t, user.Password.Set(random.Password()))
+
+	identity := &store.Identity{Provider: "google", Subject: random.String(20), Email: user.Email}
+
+	err := testModels.CreateUserWithIdentityTX(user, identity)
+	require.NoError(t, err)
+	require.Equal(t, user.ID, identity.UserID)
+
+	t.Run("duplicate subject case for create user with identity", func(t *testing.T) {
+		newUser := &store.User{Name: random.Name(), Email: random.Email(), IsActivated: true}
+		require.NoError(t, newUser.Password.Set(random.Password()))
+
+		err := testModels.CreateUserWithIdentityTX(newUser, &store.Identity{Provider: "google", Subject: identity.Subject})
+		require.ErrorIs(t, err, store.ErrDuplicateIdentity)
+
+		// The user is not inserted when the identity cannot be linked.
+		_, err = testModels.Users.GetByEmail(newUser.Email)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
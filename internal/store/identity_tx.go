@@ -0,0 +1,58 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// CreateUserWithIdentityTX inserts the user and links the identity to it.
+func (m *Models) CreateUserWithIdentityTX(user *User, identity *Identity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Users.Insert(user); err != nil {
+		return err
+	}
+
+	identity.UserID = user.ID
+
+	if err := txModels.Identities.Insert(identity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LinkIdentityTX updates the user and links the identity to it.
+func (m *Models) LinkIdentityTX(user *User, identity *Identity) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Users.Update(user); err != nil {
+		return err
+	}
+
+	identity.UserID = user.ID
+
+	if err := txModels.Identities.Insert(identity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
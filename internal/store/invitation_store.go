@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Invitation is a token in the invitation scope which lets the owner of the
+// email join the account. The user of the token is the inviter.
+type Invitation struct {
+	Plaintext string    `json:"-"`
+	Hash      []byte    `json:"-"`
+	InviterID int64     `json:"inviterID"`
+	AccountID int64     `json:"accountID"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Expiry    time.Time `json:"expiry"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type invitationModel struct {
+	DB DBTX
+}
+
+// New creates an invitation and replaces the pending invitation of the email for the
+// same account if there is any. The email is stored in lowercase so that it matches
+// the email of the user however either of them is capitalized.
+func (m *invitationModel) New(inviterID int64, accountID int64, email string, role string, ttl time.Duration) (*Invitation, error) {
+	email = strings.ToLower(email)
+
+	token, err := generateToken(inviterID, ttl, ScopeInvitation)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &Invitation{
+		Plaintext: token.Plaintext,
+		Hash:      token.Hash,
+		InviterID: inviterID,
+		AccountID: accountID,
+		Email:     email,
+		Role:      role,
+		Expiry:    token.Expiry,
+	}
+
+	if err := m.Delete(accountID, email); err != nil && !errors.Is(err, ErrRecordNotFound) {
+		return nil, err
+	}
+
+	query := `INSERT INTO tokens (hash, user_id, expiry, scope, account_id, email, role)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING created_at`
+
+	args := []interface{}{
+		invitation.Hash,
+		invitation.InviterID,
+		invitation.Expiry,
+		ScopeInvitation,
+		invitation.AccountID,
+		invitation.Email,
+		invitation.Role,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := m.DB.QueryRowContext(ctx, query, args...).Scan(&invitation.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+func (m *invitationModel) GetByToken(tokenPlaintext string) (*Invitation, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `SELECT hash, user_id, account_id, email, role, expiry, created_at
+	FROM tokens
+	WHERE hash = $1 AND scope = $2 AND expiry > $3`
+
+	args := []interface{}{tokenHash[:], ScopeInvitation, time.Now()}
+
+	var invitation Invitation
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(
+		&invitation.Hash,
+		&invitation.InviterID,
+		&invitation.AccountID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.Expiry,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (m *invitationModel) GetAllByAccountID(accountID int64) ([]*Invitation, error) {
+	query := `SELECT hash, user_id, account_id, email, role, expiry, created_at
+	FROM tokens
+	WHERE account_id = $1 AND scope = $2 AND expiry > $3
+	ORDER BY created_at DESC`
+
+	return m.getAll(query, accountID, ScopeInvitation, time.Now())
+}
+
+func (m *invitationModel) GetAllByEmail(email string) ([]*Invitation, error) {
+	query := `SELECT hash, user_id, account_id, email, role, expiry, created_at
+	FROM tokens
+	WHERE email = $1 AND scope = $2 AND expiry > $3
+	ORDER BY created_at ASC`
+
+	return m.getAll(query, strings.ToLower(email), ScopeInvitation, time.Now())
+}
+
+func (m *invitationModel) getAll(query string, args ...interface{}) ([]*Invitation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []*Invitation{}
+
+	for rows.Next() {
+		var invitation Invitation
+
+		err := rows.Scan(
+			&invitation.Hash,
+			&invitation.InviterID,
+			&invitation.AccountID,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.Expiry,
+			&invitation.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		invitations = append(invitations, &invitation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (m *invitationModel) Delete(accountID int64, email string) error {
+	query := `DELETE FROM tokens
+	WHERE account_id = $1 AND email = $2 AND scope = $3`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, accountID, strings.ToLower(email), ScopeInvitation)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
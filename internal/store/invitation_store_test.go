@@ -0,0 +1,131 @@
+package store_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomInvitation(t *testing.T) *store.Invitation {
+	account := createRandomAccount(t)
+	email := random.Email()
+
+	invitation, err := testModels.Invitations.New(account.OwnerID, account.ID, email, store.RoleEditor, time.Hour)
+	require.NoError(t, err)
+	require.NotEmpty(t, invitation)
+
+	require.NotEmpty(t, invitation.Plaintext)
+	require.Equal(t, account.OwnerID, invitation.InviterID)
+	require.Equal(t, account.ID, invitation.AccountID)
+	require.Equal(t, email, invitation.Email)
+	require.Equal(t, store.RoleEditor, invitation.Role)
+	require.WithinDuration(t, time.Now().Add(time.Hour), invitation.Expiry, time.Second)
+
+	return invitation
+}
+
+func TestInvitationModel_New(t *testing.T) {
+	invitation1 := createRandomInvitation(t)
+
+	t.Run("replaces the pending invitation", func(t *testing.T) {
+		invitation2, err := testModels.Invitations.New(invitation1.InviterID, invitation1.AccountID, invitation1.Email, store.RoleViewer, time.Hour)
+		require.NoError(t, err)
+
+		invitations, err := testModels.Invitations.GetAllByAccountID(invitation1.AccountID)
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		require.Equal(t, invitation2.Hash, invitations[0].Hash)
+		require.Equal(t, store.RoleViewer, invitations[0].Role)
+	})
+}
+
+func TestInvitationModel_GetByToken(t *testing.T) {
+	invitation1 := createRandomInvitation(t)
+
+	t.Run("success case for get invitation by token", func(t *testing.T) {
+		invitation2, err := testModels.Invitations.GetByToken(invitation1.Plaintext)
+		require.NoError(t, err)
+		require.NotEmpty(t, invitation2)
+
+		require.Equal(t, invitation1.AccountID, invitation2.AccountID)
+		require.Equal(t, invitation1.Email, invitation2.Email)
+	})
+
+	t.Run("not found case for get invitation by token", func(t *testing.T) {
+		invitation2, err := testModels.Invitations.GetByToken(random.String(26))
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+		require.Empty(t, invitation2)
+	})
+}
+
+func TestInvitationModel_GetAllByEmail(t *testing.T) {
+	account := createRandomAccount(t)
+	email := random.Email()
+
+	invitation, err := testModels.Invitations.New(account.OwnerID, account.ID, strings.ToUpper(email), store.RoleEditor, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, email, invitation.Email)
+
+	for _, e := range []string{email, strings.ToUpper(email), strings.ToUpper(email[:1]) + email[1:]} {
+		invitations, err := testModels.Invitations.GetAllByEmail(e)
+		require.NoError(t, err)
+		require.Len(t, invitations, 1)
+		require.Equal(t, invitation.Hash, invitations[0].Hash)
+	}
+
+	err = testModels.Invitations.Delete(account.ID, strings.ToUpper(email))
+	require.NoError(t, err)
+}
+
+func TestInvitationModel_Delete(t *testing.T) {
+	invitation := createRandomInvitation(t)
+
+	err := testModels.Invitations.Delete(invitation.AccountID, invitation.Email)
+	require.NoError(t, err)
+
+	invitations, err := testModels.Invitations.GetAllByEmail(invitation.Email)
+	require.NoError(t, err)
+	require.Empty(t, invitations)
+
+	err = testModels.Invitations.Delete(invitation.AccountID, invitation.Email)
+	require.Error(t, err)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestModels_AcceptInvitationTX(t *testing.T) {
+	invitation := createRandomInvitation(t)
+	user := createRandomUser(t)
+
+	err := testModels.AcceptInvitationTX(invitation, store.Actor{UserID: user.ID})
+	require.NoError(t, err)
+
+	member, err := testModels.Accounts.GetMember(invitation.AccountID, user.ID)
+	require.NoError(t, err)
+	require.Equal(t, invitation.Role, member.Role)
+
+	_, err = testModels.Invitations.GetByToken(invitation.Plaintext)
+	require.Error(t, err)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestModels_AcceptInvitationTX_DeletedAccount(t *testing.T) {
+	invitation := createRandomInvitation(t)
+	user := createRandomUser(t)
+
+	account, err := testModels.Accounts.Get(invitation.AccountID)
+	require.NoError(t, err)
+
+	err = testModels.Accounts.Delete(account.ID, account.OwnerID)
+	require.NoError(t, err)
+
+	err = testModels.AcceptInvitationTX(invitation, store.Actor{UserID: user.ID})
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	_, err = testModels.Accounts.GetMember(invitation.AccountID, user.ID)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// AcceptInvitationTX adds the actor to the account of the invitation with the invited
+// role and removes the invitation. It returns ErrRecordNotFound if the account is deleted.
+func (m *Models) AcceptInvitationTX(invitation *Invitation, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if _, err := txModels.Accounts.Get(invitation.AccountID); err != nil {
+		return err
+	}
+
+	// The user might have been added to the account directly after
+	// the invitation was sent, so the current role is kept then.
+	_, err = txModels.Accounts.GetMember(invitation.AccountID, actor.UserID)
+	if err != nil {
+		if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		if err := txModels.Accounts.AddUser(actor.UserID, invitation.AccountID, invitation.Role); err != nil {
+			return err
+		}
+
+		after := &memberAudit{UserID: actor.UserID, Role: invitation.Role}
+
+		if err := txModels.AuditLogs.Record(actor, invitation.AccountID, AuditCreate, AuditEntityMember, actor.UserID, nil, after); err != nil {
+			return err
+		}
+	}
+
+	if err := txModels.Invitations.Delete(invitation.AccountID, invitation.Email); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
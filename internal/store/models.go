@@ -20,31 +20,70 @@ type DBTX interface {
 }
 
 type Models struct {
-	DB           *sql.DB
-	Users        userModel
-	Transactions transactionModel
-	Tokens       tokenModel
-	Accounts     accountModel
-	Statistics   statisticModel
+	DB            *sql.DB
+	Users         userModel
+	Transactions  transactionModel
+	Tokens        tokenModel
+	Accounts      accountModel
+	Statistics    statisticModel
+	Rates         exchangeRateModel
+	Reports       reportModel
+	Anomalies     anomalyModel
+	Invitations   invitationModel
+	AuditLogs     auditModel
+	Revisions     revisionModel
+	RefreshTokens refreshTokenModel
+	Sessions      sessionModel
+	TwoFactors    twoFactorModel
+	RecoveryCodes recoveryCodeModel
+	APITokens     apiTokenModel
+	Identities    identityModel
+	EmailChanges  emailChangeModel
 }
 
 func NewModels(db *sql.DB) *Models {
 	return &Models{
-		DB:           db,
-		Users:        userModel{DB: db},
-		Transactions: transactionModel{DB: db},
-		Tokens:       tokenModel{DB: db},
-		Accounts:     accountModel{DB: db},
-		Statistics:   statisticModel{DB: db},
+		DB:            db,
+		Users:         userModel{DB: db},
+		Transactions:  transactionModel{DB: db},
+		Tokens:        tokenModel{DB: db},
+		Accounts:      accountModel{DB: db},
+		Statistics:    statisticModel{DB: db},
+		Rates:         exchangeRateModel{DB: db},
+		Reports:       reportModel{DB: db},
+		Anomalies:     anomalyModel{DB: db},
+		Invitations:   invitationModel{DB: db},
+		AuditLogs:     auditModel{DB: db},
+		Revisions:     revisionModel{DB: db},
+		RefreshTokens: refreshTokenModel{DB: db},
+		Sessions:      sessionModel{DB: db},
+		TwoFactors:    twoFactorModel{DB: db},
+		RecoveryCodes: recoveryCodeModel{DB: db},
+		APITokens:     apiTokenModel{DB: db},
+		Identities:    identityModel{DB: db},
+		EmailChanges:  emailChangeModel{DB: db},
 	}
 }
 
 func NewModelsWithTX(tx *sql.Tx) *Models {
 	return &Models{
-		Users:        userModel{DB: tx},
-		Transactions: transactionModel{DB: tx},
-		Tokens:       tokenModel{DB: tx},
-		Accounts:     accountModel{DB: tx},
-		Statistics:   statisticModel{DB: tx},
+		Users:         userModel{DB: tx},
+		Transactions:  transactionModel{DB: tx},
+		Tokens:        tokenModel{DB: tx},
+		Accounts:      accountModel{DB: tx},
+		Statistics:    statisticModel{DB: tx},
+		Rates:         exchangeRateModel{DB: tx},
+		Reports:       reportModel{DB: tx},
+		Anomalies:     anomalyModel{DB: tx},
+		Invitations:   invitationModel{DB: tx},
+		AuditLogs:     auditModel{DB: tx},
+		Revisions:     revisionModel{DB: tx},
+		RefreshTokens: refreshTokenModel{DB: tx},
+		Sessions:      sessionModel{DB: tx},
+		TwoFactors:    twoFactorModel{DB: tx},
+		RecoveryCodes: recoveryCodeModel{DB: tx},
+		APITokens:     apiTokenModel{DB: tx},
+		Identities:    identityModel{DB: tx},
+		EmailChanges:  emailChangeModel{DB: tx},
 	}
 }
@@ -0,0 +1,81 @@
+package store
+
+const (
+	RoleOwner       = "owner"
+	RoleAdmin       = "admin"
+	RoleEditor      = "editor"
+	RoleContributor = "contributor"
+	RoleViewer      = "viewer"
+)
+
+const (
+	PermissionReadAccount        = "account:read"
+	PermissionUpdateAccount      = "account:update"
+	PermissionDeleteAccount      = "account:delete"
+	PermissionTransferAccount    = "account:transfer"
+	PermissionManageMembers      = "members:manage"
+	PermissionCreateTransaction  = "transactions:create"
+	PermissionEditOwnTransaction = "transactions:edit-own"
+	PermissionEditAnyTransaction = "transactions:edit-any"
+)
+
+var rolePermissions = map[string][]string{
+	RoleOwner: {
+		PermissionReadAccount,
+		PermissionUpdateAccount,
+		PermissionDeleteAccount,
+		PermissionTransferAccount,
+		PermissionManageMembers,
+		PermissionCreateTransaction,
+		PermissionEditOwnTransaction,
+		PermissionEditAnyTransaction,
+	},
+	RoleAdmin: {
+		PermissionReadAccount,
+		PermissionUpdateAccount,
+		PermissionManageMembers,
+		PermissionCreateTransaction,
+		PermissionEditOwnTransaction,
+		PermissionEditAnyTransaction,
+	},
+	RoleEditor: {
+		PermissionReadAccount,
+		PermissionCreateTransaction,
+		PermissionEditOwnTransaction,
+		PermissionEditAnyTransaction,
+	},
+	RoleContributor: {
+		PermissionReadAccount,
+		PermissionCreateTransaction,
+		PermissionEditOwnTransaction,
+	},
+	RoleViewer: {
+		PermissionReadAccount,
+	},
+}
+
+// Member is a user of an account with the role of the user in that account.
+type Member struct {
+	*User
+	Role string `json:"role"`
+}
+
+// Can reports whether the role of the member grants the permission.
+func (m *Member) Can(permission string) bool {
+	for _, value := range rolePermissions[m.Role] {
+		if value == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanEditTransaction reports whether the member can update or delete the transaction.
+func (m *Member) CanEditTransaction(ts *Transaction) bool {
+	if m.Can(PermissionEditAnyTransaction) {
+		return true
+	}
+
+	return m.Can(PermissionEditOwnTransaction) && ts.UserID == m.ID
+}
@@ -0,0 +1,35 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMember_CanEditTransaction(t *testing.T) {
+	user := &store.User{ID: 1}
+	own := &store.Transaction{UserID: 1}
+	other := &store.Transaction{UserID: 2}
+
+	testCases := []struct {
+		role  string
+		own   bool
+		other bool
+	}{
+		{role: store.RoleOwner, own: true, other: true},
+		{role: store.RoleAdmin, own: true, other: true},
+		{role: store.RoleEditor, own: true, other: true},
+		{role: store.RoleContributor, own: true, other: false},
+		{role: store.RoleViewer, own: false, other: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.role, func(t *testing.T) {
+			member := &store.Member{User: user, Role: tc.role}
+
+			require.Equal(t, tc.own, member.CanEditTransaction(own))
+			require.Equal(t, tc.other, member.CanEditTransaction(other))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token is reused")
+
+// RefreshToken is exchanged for a new access token and a new refresh token. The
+// tokens which are rotated from the same login belong to the same family.
+type RefreshToken struct {
+	Plaintext string     `json:"token"`
+	Hash      []byte     `json:"-"`
+	UserID    int64      `json:"-"`
+	Family    string     `json:"-"`
+	Expiry    time.Time  `json:"expiry"`
+	CreatedAt time.Time  `json:"-"`
+	UsedAt    *time.Time `json:"-"`
+	RevokedAt *time.Time `json:"-"`
+}
+
+type refreshTokenModel struct {
+	DB DBTX
+}
+
+// New creates a refresh token in the family. A new family is started when the family is empty.
+func (m *refreshTokenModel) New(userID int64, family string, ttl time.Duration) (*RefreshToken, error) {
+	plaintext, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	if family == "" {
+		family, err = randomString(16)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hash := sha256.Sum256([]byte(plaintext))
+
+	token := &RefreshToken{
+		Plaintext: plaintext,
+		Hash:      hash[:],
+		UserID:    userID,
+		Family:    family,
+		Expiry:    time.Now().Add(ttl),
+	}
+
+	query := `INSERT INTO refresh_tokens (hash, user_id, family, expiry)
+	VALUES ($1, $2, $3, $4)
+	RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = m.DB.QueryRowContext(ctx, query, token.Hash, token.UserID, token.Family, token.Expiry).Scan(&token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// GetByToken returns the unexpired refresh token even if it is used or revoked,
+// so that the reuse of a rotated token can be detected.
+func (m *refreshTokenModel) GetByToken(tokenPlaintext string) (*RefreshToken, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `SELECT hash, user_id, family, expiry, created_at, used_at, revoked_at
+	FROM refresh_tokens
+	WHERE hash = $1 AND expiry > $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var token RefreshToken
+
+	err := m.DB.QueryRowContext(ctx, query, tokenHash[:], time.Now()).Scan(
+		&token.Hash,
+		&token.UserID,
+		&token.Family,
+		&token.Expiry,
+		&token.CreatedAt,
+		&token.UsedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkUsed marks the token as rotated. It returns ErrRecordNotFound
+// if the token is already used or revoked.
+func (m *refreshTokenModel) MarkUsed(hash []byte) error {
+	query := `UPDATE refresh_tokens SET used_at = now()
+	WHERE hash = $1 AND used_at IS NULL AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, hash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m *refreshTokenModel) RevokeFamily(family string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = now()
+	WHERE family = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, family)
+
+	return err
+}
+
+func (m *refreshTokenModel) RevokeAllForUser(userID int64) error {
+	query := `UPDATE refresh_tokens SET revoked_at = now()
+	WHERE user_id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+
+	return err
+}
@@ -0,0 +1,81 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRefreshToken(t *testing.T) *store.RefreshToken {
+	user := createRandomUser(t)
+
+	token, err := testModels.RefreshTokens.New(user.ID, "", time.Hour)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	require.Equal(t, user.ID, token.UserID)
+	require.NotEmpty(t, token.Family)
+	require.NotEmpty(t, token.Plaintext)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Second)
+
+	return token
+}
+
+func TestRefreshTokenModel_GetByToken(t *testing.T) {
+	token := createRandomRefreshToken(t)
+
+	got, err := testModels.RefreshTokens.GetByToken(token.Plaintext)
+	require.NoError(t, err)
+	require.Equal(t, token.Hash, got.Hash)
+	require.Equal(t, token.UserID, got.UserID)
+	require.Equal(t, token.Family, got.Family)
+	require.Nil(t, got.UsedAt)
+	require.Nil(t, got.RevokedAt)
+
+	_, err = testModels.RefreshTokens.GetByToken("invalid")
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestModels_RotateRefreshTokenTX(t *testing.T) {
+	token := createRandomRefreshToken(t)
+
+	rotated, err := testModels.RotateRefreshTokenTX(token, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, token.Family, rotated.Family)
+	require.NotEqual(t, token.Plaintext, rotated.Plaintext)
+
+	used, err := testModels.RefreshTokens.GetByToken(token.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, used.UsedAt)
+
+	t.Run("reuse revokes the family", func(t *testing.T) {
+		_, err := testModels.RotateRefreshTokenTX(used, time.Hour)
+		require.ErrorIs(t, err, store.ErrRefreshTokenReused)
+
+		revoked, err := testModels.RefreshTokens.GetByToken(rotated.Plaintext)
+		require.NoError(t, err)
+		require.NotNil(t, revoked.RevokedAt)
+
+		_, err = testModels.RotateRefreshTokenTX(revoked, time.Hour)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
+
+func TestRefreshTokenModel_RevokeAllForUser(t *testing.T) {
+	token := createRandomRefreshToken(t)
+
+	other, err := testModels.RefreshTokens.New(token.UserID, "", time.Hour)
+	require.NoError(t, err)
+	require.NotEqual(t, token.Family, other.Family)
+
+	err = testModels.RefreshTokens.RevokeAllForUser(token.UserID)
+	require.NoError(t, err)
+
+	for _, plaintext := range []string{token.Plaintext, other.Plaintext} {
+		got, err := testModels.RefreshTokens.GetByToken(plaintext)
+		require.NoError(t, err)
+		require.NotNil(t, got.RevokedAt)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RotateRefreshTokenTX replaces the refresh token with a new one in the same family.
+// When the token was already rotated, it is stolen or leaked, so the whole family
+// is revoked and ErrRefreshTokenReused is returned.
+func (m *Models) RotateRefreshTokenTX(token *RefreshToken, ttl time.Duration) (*RefreshToken, error) {
+	if token.RevokedAt != nil {
+		return nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.RefreshTokens.MarkUsed(token.Hash); err != nil {
+		if !errors.Is(err, ErrRecordNotFound) {
+			return nil, err
+		}
+
+		if err := m.RefreshTokens.RevokeFamily(token.Family); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrRefreshTokenReused
+	}
+
+	newToken, err := txModels.RefreshTokens.New(token.UserID, token.Family, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return newToken, nil
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// MonthlyFlow is the sum of the daily statistics of an account for a month.
+type MonthlyFlow struct {
+	AccountID int64     `json:"accountID"`
+	Currency  string    `json:"currency"`
+	Month     time.Time `json:"month"`
+	Earning   float64   `json:"earning"`
+	Spending  float64   `json:"spending"`
+}
+
+type reportModel struct {
+	DB DBTX
+}
+
+// GetMonthlyFlows returns the monthly earning and spending of every account
+// the user is a member of between the given dates.
+func (m *reportModel) GetMonthlyFlows(userID int64, after time.Time, before time.Time) ([]*MonthlyFlow, error) {
+	query := `SELECT s.account_id, a.currency, date_trunc('month', s.date)::date AS month, SUM(s.earning), SUM(s.spending)
+	FROM statistics s
+	INNER JOIN users_accounts u ON u.account_id = s.account_id
+	INNER JOIN accounts a ON a.id = s.account_id
+	WHERE u.user_id = $1 AND a.deleted_at IS NULL AND s.date >= $2 AND s.date < $3
+	GROUP BY s.account_id, a.currency, month
+	ORDER BY month ASC, s.account_id ASC`
+
+	return m.getFlows(query, userID, after, before)
+}
+
+// GetMonthlyCashFlows returns the monthly earning and spending like GetMonthlyFlows
+// from the transactions, so that the transfers between the accounts are left out.
+func (m *reportModel) GetMonthlyCashFlows(userID int64, after time.Time, before time.Time) ([]*MonthlyFlow, error) {
+	query := `SELECT t.account_id, a.currency, date_trunc('month', t.payday)::date AS month,
+	COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'income'), 0),
+	COALESCE(SUM(t.amount) FILTER (WHERE t.type = 'expense'), 0)
+	FROM transactions t
+	INNER JOIN users_accounts u ON u.account_id = t.account_id
+	INNER JOIN accounts a ON a.id = t.account_id
+	WHERE u.user_id = $1 AND a.deleted_at IS NULL AND t.deleted_at IS NULL
+	AND t.payday >= $2 AND t.payday < $3 AND NOT COALESCE($4 = ANY(t.tags), false)
+	GROUP BY t.account_id, a.currency, month
+	ORDER BY month ASC, t.account_id ASC`
+
+	return m.getFlows(query, userID, after, before, TagTransfer)
+}
+
+func (m *reportModel) getFlows(query string, args ...interface{}) ([]*MonthlyFlow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	flows := []*MonthlyFlow{}
+
+	for rows.Next() {
+		var flow MonthlyFlow
+
+		err := rows.Scan(
+			&flow.AccountID,
+			&flow.Currency,
+			&flow.Month,
+			&flow.Earning,
+			&flow.Spending,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		flows = append(flows, &flow)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return flows, nil
+}
@@ -0,0 +1,61 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportModel_GetMonthlyFlows(t *testing.T) {
+	ts, account, stat := createRandomTX(t)
+
+	err := testModels.Accounts.AddUser(account.OwnerID, account.ID, store.RoleOwner)
+	require.NoError(t, err)
+
+	flows, err := testModels.Reports.GetMonthlyFlows(account.OwnerID, time.Unix(0, 0), time.Now().AddDate(3, 0, 0))
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+
+	require.Equal(t, account.ID, flows[0].AccountID)
+	require.Equal(t, account.Currency, flows[0].Currency)
+	require.Equal(t, stat.Earning, flows[0].Earning)
+	require.Equal(t, stat.Spending, flows[0].Spending)
+	require.Equal(t, ts.Payday.Year(), flows[0].Month.Year())
+	require.Equal(t, ts.Payday.Month(), flows[0].Month.Month())
+	require.Equal(t, 1, flows[0].Month.Day())
+}
+
+func TestReportModel_GetMonthlyCashFlows(t *testing.T) {
+	ts, account, stat := createRandomTX(t)
+
+	err := testModels.Accounts.AddUser(account.OwnerID, account.ID, store.RoleOwner)
+	require.NoError(t, err)
+
+	transfer := &store.Transaction{
+		UserID:    ts.UserID,
+		AccountID: account.ID,
+		Type:      ts.Type,
+		Title:     "transfer to savings",
+		Tags:      []string{store.TagTransfer},
+		Amount:    100,
+		Payday:    ts.Payday,
+	}
+
+	err = testModels.CreateTransactionTX(transfer, account, stat, store.Actor{UserID: ts.UserID})
+	require.NoError(t, err)
+
+	flows, err := testModels.Reports.GetMonthlyCashFlows(account.OwnerID, time.Unix(0, 0), time.Now().AddDate(3, 0, 0))
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+
+	require.Equal(t, account.ID, flows[0].AccountID)
+	require.InDelta(t, ts.Amount, flows[0].Earning+flows[0].Spending, 0.001)
+
+	if ts.Type == "income" {
+		require.Zero(t, flows[0].Spending)
+	} else {
+		require.Zero(t, flows[0].Earning)
+	}
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Revision is a previous version of a transaction. The editor is the user
+// who replaced the revision with the next version at the creation time.
+type Revision struct {
+	ID            int64     `json:"id"`
+	TransactionID int64     `json:"transactionID"`
+	Version       int       `json:"version"`
+	EditorID      int64     `json:"editorID"`
+	Type          string    `json:"type"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description,omitempty"`
+	Tags          []string  `json:"tags,omitempty"`
+	Amount        float64   `json:"amount"`
+	Payday        time.Time `json:"payday"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// revisionContent is the part of the revision that is compared for the diffs.
+type revisionContent struct {
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Amount      float64   `json:"amount"`
+	Payday      time.Time `json:"payday"`
+}
+
+// NewRevision returns the current version of the transaction as a revision.
+func NewRevision(ts *Transaction, editorID int64) *Revision {
+	return &Revision{
+		TransactionID: ts.ID,
+		Version:       ts.Version,
+		EditorID:      editorID,
+		Type:          ts.Type,
+		Title:         ts.Title,
+		Description:   ts.Description,
+		Tags:          ts.Tags,
+		Amount:        ts.Amount,
+		Payday:        ts.Payday,
+	}
+}
+
+// Apply sets the fields of the transaction to the values of the revision.
+func (r *Revision) Apply(ts *Transaction) {
+	ts.Type = r.Type
+	ts.Title = r.Title
+	ts.Description = r.Description
+	ts.Tags = r.Tags
+	ts.Amount = r.Amount
+	ts.Payday = r.Payday
+}
+
+// Diff returns the fields which are changed from the revision to the next one.
+func (r *Revision) Diff(next *Revision) (json.RawMessage, json.RawMessage, error) {
+	return diff(r.content(), next.content())
+}
+
+func (r *Revision) content() *revisionContent {
+	return &revisionContent{
+		Type:        r.Type,
+		Title:       r.Title,
+		Description: r.Description,
+		Tags:        r.Tags,
+		Amount:      r.Amount,
+		Payday:      r.Payday,
+	}
+}
+
+type revisionModel struct {
+	DB DBTX
+}
+
+func (m *revisionModel) Insert(revision *Revision) error {
+	query := `INSERT INTO transaction_revisions (transaction_id, version, editor_id, type, title, description, tags, amount, payday)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	RETURNING id, created_at`
+
+	args := []interface{}{
+		revision.TransactionID,
+		revision.Version,
+		revision.EditorID,
+		revision.Type,
+		revision.Title,
+		revision.Description,
+		pq.Array(revision.Tags),
+		revision.Amount,
+		revision.Payday,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&revision.ID, &revision.CreatedAt)
+}
+
+func (m *revisionModel) Get(transactionID int64, version int) (*Revision, error) {
+	query := `SELECT id, transaction_id, version, editor_id, type, title, description, tags, amount, payday, created_at
+	FROM transaction_revisions
+	WHERE transaction_id = $1 AND version = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var revision Revision
+
+	err := m.DB.QueryRowContext(ctx, query, transactionID, version).Scan(
+		&revision.ID,
+		&revision.TransactionID,
+		&revision.Version,
+		&revision.EditorID,
+		&revision.Type,
+		&revision.Title,
+		&revision.Description,
+		pq.Array(&revision.Tags),
+		&revision.Amount,
+		&revision.Payday,
+		&revision.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// GetAllByTransactionID returns the revisions of the transaction from the oldest version.
+func (m *revisionModel) GetAllByTransactionID(transactionID int64) ([]*Revision, error) {
+	query := `SELECT id, transaction_id, version, editor_id, type, title, description, tags, amount, payday, created_at
+	FROM transaction_revisions
+	WHERE transaction_id = $1
+	ORDER BY version ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []*Revision{}
+
+	for rows.Next() {
+		var revision Revision
+
+		err := rows.Scan(
+			&revision.ID,
+			&revision.TransactionID,
+			&revision.Version,
+			&revision.EditorID,
+			&revision.Type,
+			&revision.Title,
+			&revision.Description,
+			pq.Array(&revision.Tags),
+			&revision.Amount,
+			&revision.Payday,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,66 @@
+package store_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevisionModel_GetAllByTransactionID(t *testing.T) {
+	oldTS, account, stat := createRandomTX(t)
+	editor := createRandomUser(t)
+
+	newTS := *oldTS
+	newTS.Title = oldTS.Title + " updated"
+
+	err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat, store.Actor{UserID: editor.ID})
+	require.NoError(t, err)
+
+	revisions, err := testModels.Revisions.GetAllByTransactionID(oldTS.ID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	revision := revisions[0]
+	require.Equal(t, oldTS.Version, revision.Version)
+	require.Equal(t, editor.ID, revision.EditorID)
+	require.Equal(t, oldTS.Title, revision.Title)
+	require.Equal(t, oldTS.Amount, revision.Amount)
+
+	t.Run("success case for get revision", func(t *testing.T) {
+		got, err := testModels.Revisions.Get(oldTS.ID, oldTS.Version)
+		require.NoError(t, err)
+		require.Equal(t, revision.ID, got.ID)
+	})
+
+	t.Run("not found case for get revision", func(t *testing.T) {
+		got, err := testModels.Revisions.Get(oldTS.ID, newTS.Version)
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+		require.Nil(t, got)
+	})
+}
+
+func TestRevision_Diff(t *testing.T) {
+	ts := createRandomTransaction(t)
+
+	revision := store.NewRevision(&ts, ts.UserID)
+
+	next := *revision
+	next.Amount = revision.Amount + 1
+
+	before, after, err := revision.Diff(&next)
+	require.NoError(t, err)
+
+	var beforeFields, afterFields map[string]interface{}
+	require.NoError(t, json.Unmarshal(before, &beforeFields))
+	require.NoError(t, json.Unmarshal(after, &afterFields))
+
+	require.Equal(t, map[string]interface{}{"amount": revision.Amount}, beforeFields)
+	require.Equal(t, map[string]interface{}{"amount": next.Amount}, afterFields)
+
+	reverted := ts
+	next.Apply(&reverted)
+	require.Equal(t, next.Amount, reverted.Amount)
+}
@@ -0,0 +1,202 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Session is a login of the user on a device. The refresh tokens of
+// the session belong to the refresh token family of the session.
+type Session struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"-"`
+	Family     string     `json:"-"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt time.Time  `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
+
+type sessionModel struct {
+	DB DBTX
+}
+
+func (m *sessionModel) Insert(session *Session) error {
+	query := `INSERT INTO sessions (user_id, family, device, ip)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at, last_used_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	args := []interface{}{session.UserID, session.Family, session.Device, session.IP}
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+}
+
+func (m *sessionModel) Get(id int64) (*Session, error) {
+	query := `SELECT id, user_id, family, device, ip, created_at, last_used_at, revoked_at
+	FROM sessions
+	WHERE id = $1`
+
+	return m.get(query, id)
+}
+
+func (m *sessionModel) GetByFamily(family string) (*Session, error) {
+	query := `SELECT id, user_id, family, device, ip, created_at, last_used_at, revoked_at
+	FROM sessions
+	WHERE family = $1`
+
+	return m.get(query, family)
+}
+
+func (m *sessionModel) get(query string, arg interface{}) (*Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var session Session
+
+	err := m.DB.QueryRowContext(ctx, query, arg).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.Family,
+		&session.Device,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// GetAllActiveByUserID returns the sessions of the user which are not revoked from the last used one.
+func (m *sessionModel) GetAllActiveByUserID(userID int64) ([]*Session, error) {
+	query := `SELECT id, user_id, family, device, ip, created_at, last_used_at, revoked_at
+	FROM sessions
+	WHERE user_id = $1 AND revoked_at IS NULL
+	ORDER BY last_used_at DESC, id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		var session Session
+
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.Family,
+			&session.Device,
+			&session.IP,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.RevokedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch updates the last used time of the session.
+func (m *sessionModel) Touch(id int64) error {
+	query := `UPDATE sessions SET last_used_at = now() WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, id)
+
+	return err
+}
+
+// Revoke revokes the session of the user. It returns ErrRecordNotFound
+// if the session does not exist or is already revoked.
+func (m *sessionModel) Revoke(id int64, userID int64) error {
+	query := `UPDATE sessions SET revoked_at = now()
+	WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes the sessions of the user except the given one
+// and returns the revoked sessions. Every session is revoked when exceptID is 0.
+func (m *sessionModel) RevokeAllForUser(userID int64, exceptID int64) ([]*Session, error) {
+	query := `UPDATE sessions SET revoked_at = now()
+	WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+	RETURNING id, user_id, family`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID, exceptID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		var session Session
+
+		if err := rows.Scan(&session.ID, &session.UserID, &session.Family); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,90 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, userID int64) (*store.Session, *store.RefreshToken) {
+	session := &store.Session{
+		UserID: userID,
+		Device: "Mozilla/5.0",
+		IP:     "127.0.0.1",
+	}
+
+	token, err := testModels.CreateSessionTX(session, time.Hour)
+	require.NoError(t, err)
+	require.NotZero(t, session.ID)
+	require.Equal(t, token.Family, session.Family)
+	require.Equal(t, userID, token.UserID)
+
+	return session, token
+}
+
+func TestModels_CreateSessionTX(t *testing.T) {
+	user := createRandomUser(t)
+	session, _ := createRandomSession(t, user.ID)
+
+	got, err := testModels.Sessions.Get(session.ID)
+	require.NoError(t, err)
+	require.Equal(t, session.Family, got.Family)
+	require.Equal(t, session.Device, got.Device)
+	require.Equal(t, session.IP, got.IP)
+	require.False(t, got.IsRevoked())
+
+	got, err = testModels.Sessions.GetByFamily(session.Family)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, got.ID)
+}
+
+func TestModels_RevokeSessionTX(t *testing.T) {
+	user := createRandomUser(t)
+	session, token := createRandomSession(t, user.ID)
+
+	err := testModels.RevokeSessionTX(session)
+	require.NoError(t, err)
+
+	got, err := testModels.Sessions.Get(session.ID)
+	require.NoError(t, err)
+	require.True(t, got.IsRevoked())
+
+	refreshToken, err := testModels.RefreshTokens.GetByToken(token.Plaintext)
+	require.NoError(t, err)
+	require.NotNil(t, refreshToken.RevokedAt)
+
+	err = testModels.RevokeSessionTX(session)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestModels_RevokeSessionsTX(t *testing.T) {
+	user := createRandomUser(t)
+	current, _ := createRandomSession(t, user.ID)
+
+	for i := 0; i < 2; i++ {
+		createRandomSession(t, user.ID)
+	}
+
+	sessions, err := testModels.Sessions.GetAllActiveByUserID(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	revoked, err := testModels.RevokeSessionsTX(user.ID, current.ID)
+	require.NoError(t, err)
+	require.Len(t, revoked, 2)
+
+	sessions, err = testModels.Sessions.GetAllActiveByUserID(user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, current.ID, sessions[0].ID)
+
+	revoked, err = testModels.RevokeSessionsTX(user.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, revoked, 1)
+
+	sessions, err = testModels.Sessions.GetAllActiveByUserID(user.ID)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
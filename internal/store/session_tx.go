@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// CreateSessionTX starts a session with the first refresh token of a new family.
+func (m *Models) CreateSessionTX(session *Session, ttl time.Duration) (*RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	token, err := txModels.RefreshTokens.New(session.UserID, "", ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Family = token.Family
+
+	if err := txModels.Sessions.Insert(session); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// RevokeSessionTX revokes the session with the refresh tokens of the session.
+func (m *Models) RevokeSessionTX(session *Session) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Sessions.Revoke(session.ID, session.UserID); err != nil {
+		return err
+	}
+
+	if err := txModels.RefreshTokens.RevokeFamily(session.Family); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeSessionsTX revokes the sessions of the user except the given one with the
+// refresh tokens of the sessions and returns the revoked sessions.
+func (m *Models) RevokeSessionsTX(userID int64, exceptID int64) ([]*Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	sessions, err := txModels.Sessions.RevokeAllForUser(userID, exceptID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, session := range sessions {
+		if err := txModels.RefreshTokens.RevokeFamily(session.Family); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
@@ -4,13 +4,22 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/base32"
+	"errors"
 	"time"
 )
 
 const (
 	ScopeActivation    = "activation"
 	ScopePasswordReset = "password-reset"
+	ScopeInvitation    = "invitation"
+	ScopeTwoFactor     = "two-factor"
+	ScopeUnlock        = "unlock"
+	ScopeMagicLink     = "magic-link"
+
+	ScopeEmailChange       = "email-change"
+	ScopeEmailChangeCancel = "email-change-cancel"
 )
 
 type Token struct {
@@ -28,14 +37,12 @@ func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error
 		Scope:  scope,
 	}
 
-	randomBytes := make([]byte, 16)
-
-	_, err := rand.Read(randomBytes)
+	plaintext, err := randomString(16)
 	if err != nil {
 		return nil, err
 	}
 
-	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	token.Plaintext = plaintext
 
 	hash := sha256.Sum256([]byte(token.Plaintext))
 
@@ -44,6 +51,17 @@ func generateToken(userID int64, ttl time.Duration, scope string) (*Token, error
 	return token, nil
 }
 
+// randomString returns the base32 encoding of n random bytes.
+func randomString(n int) (string, error) {
+	randomBytes := make([]byte, n)
+
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes), nil
+}
+
 type tokenModel struct {
 	DB DBTX
 }
@@ -85,3 +103,28 @@ func (m *tokenModel) DeleteAllForUser(scope string, userID int64) error {
 
 	return err
 }
+
+// Use deletes the token if it is not expired and returns the ID of its user,
+// so that a token can only be used once even by the concurrent requests.
+func (m *tokenModel) Use(scope string, tokenPlaintext string) (int64, error) {
+	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
+
+	query := `DELETE FROM tokens
+	WHERE hash = $1 AND scope = $2 AND expiry > $3
+	RETURNING user_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var userID int64
+
+	err := m.DB.QueryRowContext(ctx, query, tokenHash[:], scope, time.Now()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrRecordNotFound
+		}
+		return 0, err
+	}
+
+	return userID, nil
+}
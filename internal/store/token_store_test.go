@@ -35,4 +35,19 @@ func TestTokenModel_DeleteAllForUser(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorIs(t, err, store.ErrRecordNotFound)
 	require.Empty(t, user)
-}
\ No newline at end of file
+}
+
+func TestTokenModel_Use(t *testing.T) {
+	token := createNewToken(t)
+
+	userID, err := testModels.Tokens.Use(store.ScopePasswordReset, token.Plaintext)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+	require.Zero(t, userID)
+
+	userID, err = testModels.Tokens.Use(token.Scope, token.Plaintext)
+	require.NoError(t, err)
+	require.Equal(t, token.UserID, userID)
+
+	_, err = testModels.Tokens.Use(token.Scope, token.Plaintext)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+}
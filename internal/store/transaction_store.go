@@ -10,20 +10,25 @@ import (
 	"github.com/lib/pq"
 )
 
+// TagTransfer is the reserved tag of the transactions which move money between the
+// accounts of the users. They change the balances but are not a part of the cash flow.
+const TagTransfer = "transfer"
+
 type Transaction struct {
-	ID          int64     `json:"id"`
-	UserID      int64     `json:"userID"`
-	AccountID   int64     `json:"accountID"`
-	Type        string    `json:"type"`
-	Title       string    `json:"title"`
-	Description string    `json:"description,omitempty"`
-	Tags        []string  `json:"tags,omitempty"`
-	Amount      float64   `json:"amount"`
-	Payday      time.Time `json:"payday"`
-	CreatedAt   time.Time `json:"createdAt"`
-	Version     int       `json:"version"`
-	User        *User     `json:"user,omitempty"`
-	Account     *Account  `json:"account,omitempty"`
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"userID"`
+	AccountID   int64      `json:"accountID"`
+	Type        string     `json:"type"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Amount      float64    `json:"amount"`
+	Payday      time.Time  `json:"payday"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	Version     int        `json:"version"`
+	User        *User      `json:"user,omitempty"`
+	Account     *Account   `json:"account,omitempty"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
 	//Receipts    []string  `json:"receipts,omitempty"`
 }
 
@@ -60,7 +65,7 @@ func (m *transactionModel) Get(id int64) (*Transaction, error) {
 FROM transactions t
 LEFT JOIN users u ON t.user_id = u.id
 LEFT JOIN accounts a ON t.account_id = a.id
-WHERE t.id = $1`
+WHERE t.id = $1 AND t.deleted_at IS NULL`
 
 	var ts Transaction
 	var user User
@@ -108,7 +113,7 @@ WHERE t.id = $1`
 
 func (m *transactionModel) Update(ts *Transaction) error {
 	query := `UPDATE transactions SET type=$1, title=$2, description=$3, tags=$4, amount=$5, payday=$6, version=version+1
-WHERE id=$7 AND version=$8
+WHERE id=$7 AND version=$8 AND deleted_at IS NULL
 RETURNING version`
 
 	args := []interface{}{
@@ -134,8 +139,8 @@ RETURNING version`
 }
 
 func (m *transactionModel) Delete(id int64, userID int64) error {
-	query := `DELETE FROM transactions
-WHERE id = $1 AND user_id = $2`
+	query := `UPDATE transactions SET deleted_at = now()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -162,7 +167,7 @@ func (m *transactionModel) GetAll(userId int64, title string, tags []string, sta
 	a.id, a.owner_id, a.title, a.description, a.total_income, a.total_expense, a.currency, a.created_at, a.version
 	FROM transactions t
 	LEFT JOIN accounts a ON t.account_id = a.id
-	WHERE t.user_id = $1 
+	WHERE t.user_id = $1 AND t.deleted_at IS NULL AND a.deleted_at IS NULL
 	AND (to_tsvector('simple', t.title) @@ to_tsquery('simple', $2) OR $2='')
 	AND (t.tags @> $7 OR $7 = '{}')
 	AND t.payday >= $3 AND t.payday < $4
@@ -237,7 +242,7 @@ func (m *transactionModel) GetAllByAccountID(accountID int64, startedAt time.Tim
 	u.id, u.email, u.name, u.created_at, u.version
 FROM transactions t
 LEFT JOIN users u ON t.user_id = u.id
-WHERE t.account_id=$1
+WHERE t.account_id=$1 AND t.deleted_at IS NULL
 AND t.payday >= $2 AND t.payday < $3
 ORDER BY t.%s %s, t.id ASC 
 LIMIT $4 OFFSET $5`, filters.sortColumn(), filters.sortDirection())
@@ -298,3 +303,219 @@ LIMIT $4 OFFSET $5`, filters.sortColumn(), filters.sortDirection())
 
 	return transactions, nil
 }
+
+// GetSimilarAmounts returns the amounts of the other transactions in the account
+// which have the same type and either the same title or a common tag.
+func (m *transactionModel) GetSimilarAmounts(ts *Transaction) ([]float64, error) {
+	query := `SELECT amount
+	FROM transactions
+	WHERE account_id = $1 AND type = $2 AND id <> $3 AND deleted_at IS NULL
+	AND (lower(title) = lower($4) OR tags && $5)`
+
+	args := []interface{}{
+		ts.AccountID,
+		ts.Type,
+		ts.ID,
+		ts.Title,
+		pq.Array(ts.Tags),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amounts := []float64{}
+
+	for rows.Next() {
+		var amount float64
+
+		if err := rows.Scan(&amount); err != nil {
+			return nil, err
+		}
+
+		amounts = append(amounts, amount)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return amounts, nil
+}
+
+// GetDuplicateIDs returns the ids of the other transactions in the account which
+// have the same type, title and amount with a payday in the given window.
+func (m *transactionModel) GetDuplicateIDs(ts *Transaction, window time.Duration) ([]int64, error) {
+	query := `SELECT id
+	FROM transactions
+	WHERE account_id = $1 AND type = $2 AND id <> $3 AND deleted_at IS NULL
+	AND lower(title) = lower($4) AND amount = $5
+	AND payday >= $6 AND payday <= $7
+	ORDER BY id ASC`
+
+	args := []interface{}{
+		ts.AccountID,
+		ts.Type,
+		ts.ID,
+		ts.Title,
+		ts.Amount,
+		ts.Payday.Add(-window),
+		ts.Payday.Add(window),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+
+	for rows.Next() {
+		var id int64
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetDeleted returns the transaction if it is in the trash.
+func (m *transactionModel) GetDeleted(id int64) (*Transaction, error) {
+	query := `SELECT id, user_id, account_id, type, title, description, tags, amount, payday, created_at, version, deleted_at
+	FROM transactions
+	WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var ts Transaction
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&ts.ID,
+		&ts.UserID,
+		&ts.AccountID,
+		&ts.Type,
+		&ts.Title,
+		&ts.Description,
+		pq.Array(&ts.Tags),
+		&ts.Amount,
+		&ts.Payday,
+		&ts.CreatedAt,
+		&ts.Version,
+		&ts.DeletedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecordNotFound
+	}
+
+	return &ts, err
+}
+
+// GetTrash returns the deleted transactions of the accounts the user
+// is a member of from the most recently deleted.
+func (m *transactionModel) GetTrash(userID int64) ([]*Transaction, error) {
+	query := `SELECT t.id, t.user_id, t.account_id, t.type, t.title, t.description, t.tags, t.amount, t.payday, t.created_at, t.version, t.deleted_at
+	FROM transactions t
+	INNER JOIN users_accounts u ON u.account_id = t.account_id
+	INNER JOIN accounts a ON a.id = t.account_id
+	WHERE u.user_id = $1 AND t.deleted_at IS NOT NULL AND a.deleted_at IS NULL
+	ORDER BY t.deleted_at DESC, t.id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []*Transaction{}
+
+	for rows.Next() {
+		var ts Transaction
+
+		err := rows.Scan(
+			&ts.ID,
+			&ts.UserID,
+			&ts.AccountID,
+			&ts.Type,
+			&ts.Title,
+			&ts.Description,
+			pq.Array(&ts.Tags),
+			&ts.Amount,
+			&ts.Payday,
+			&ts.CreatedAt,
+			&ts.Version,
+			&ts.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		transactions = append(transactions, &ts)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (m *transactionModel) Restore(id int64) error {
+	query := `UPDATE transactions SET deleted_at = NULL
+	WHERE id = $1 AND deleted_at IS NOT NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Purge removes the transactions which were deleted before the given time.
+func (m *transactionModel) Purge(before time.Time) (int64, error) {
+	query := `DELETE FROM transactions
+	WHERE deleted_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -6,7 +6,7 @@ import (
 	"time"
 )
 
-func (m *Models) CreateTransactionTX(ts *Transaction, account *Account, statistic *Statistic) error {
+func (m *Models) CreateTransactionTX(ts *Transaction, account *Account, statistic *Statistic, actor Actor) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -22,6 +22,10 @@ func (m *Models) CreateTransactionTX(ts *Transaction, account *Account, statisti
 		return err
 	}
 
+	if err := txModels.AuditLogs.Record(actor, ts.AccountID, AuditCreate, AuditEntityTransaction, ts.ID, nil, ts); err != nil {
+		return err
+	}
+
 	if ts.Type == "income" {
 		account.TotalIncome += ts.Amount
 	} else {
@@ -64,7 +68,7 @@ func (m *Models) CreateTransactionTX(ts *Transaction, account *Account, statisti
 	return nil
 }
 
-func (m *Models) UpdateTransactionTX(newTS *Transaction, oldTS Transaction, account *Account, statistic *Statistic) error {
+func (m *Models) UpdateTransactionTX(newTS *Transaction, oldTS Transaction, account *Account, statistic *Statistic, actor Actor) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -80,6 +84,14 @@ func (m *Models) UpdateTransactionTX(newTS *Transaction, oldTS Transaction, acco
 		return err
 	}
 
+	if err := txModels.Revisions.Insert(NewRevision(&oldTS, actor.UserID)); err != nil {
+		return err
+	}
+
+	if err := txModels.AuditLogs.Record(actor, newTS.AccountID, AuditUpdate, AuditEntityTransaction, newTS.ID, &oldTS, newTS); err != nil {
+		return err
+	}
+
 	if newTS.Amount != oldTS.Amount {
 		if oldTS.Type == "income" {
 			statistic.Earning -= oldTS.Amount - newTS.Amount
@@ -153,7 +165,7 @@ func (m *Models) UpdateTransactionTX(newTS *Transaction, oldTS Transaction, acco
 	return tx.Commit()
 }
 
-func (m *Models) DeleteTransactionTX(ts *Transaction, account *Account, stat *Statistic) error {
+func (m *Models) DeleteTransactionTX(ts *Transaction, account *Account, stat *Statistic, actor Actor) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -169,6 +181,10 @@ func (m *Models) DeleteTransactionTX(ts *Transaction, account *Account, stat *St
 		return err
 	}
 
+	if err := txModels.AuditLogs.Record(actor, ts.AccountID, AuditDelete, AuditEntityTransaction, ts.ID, ts, nil); err != nil {
+		return err
+	}
+
 	if ts.Type == "income" {
 		stat.Earning -= ts.Amount
 		account.TotalIncome -= ts.Amount
@@ -187,3 +203,65 @@ func (m *Models) DeleteTransactionTX(ts *Transaction, account *Account, stat *St
 
 	return tx.Commit()
 }
+
+// RestoreTransactionTX takes the transaction out of the trash and adds
+// its amount back to the account and to the statistic of its payday.
+func (m *Models) RestoreTransactionTX(ts *Transaction, account *Account, statistic *Statistic, actor Actor) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.Transactions.Restore(ts.ID); err != nil {
+		return err
+	}
+
+	ts.DeletedAt = nil
+
+	if err := txModels.AuditLogs.Record(actor, ts.AccountID, AuditRestore, AuditEntityTransaction, ts.ID, nil, ts); err != nil {
+		return err
+	}
+
+	if ts.Type == "income" {
+		account.TotalIncome += ts.Amount
+	} else {
+		account.TotalExpense += ts.Amount
+	}
+
+	if err := txModels.Accounts.Update(account); err != nil {
+		return err
+	}
+
+	if statistic.Version == 0 {
+		statistic.AccountID = ts.AccountID
+		statistic.Date = ts.Payday
+
+		if ts.Type == "income" {
+			statistic.Earning = ts.Amount
+		} else {
+			statistic.Spending = ts.Amount
+		}
+
+		if err := txModels.Statistics.Insert(statistic); err != nil {
+			return err
+		}
+	} else {
+		if ts.Type == "income" {
+			statistic.Earning += ts.Amount
+		} else {
+			statistic.Spending += ts.Amount
+		}
+
+		if err := txModels.Statistics.Update(statistic); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
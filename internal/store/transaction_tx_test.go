@@ -38,7 +38,7 @@ func createRandomTX(t *testing.T) (*store.Transaction, *store.Account, *store.St
 	}
 
 	stat := store.Statistic{}
-	err := testModels.CreateTransactionTX(&ts, &account, &stat)
+	err := testModels.CreateTransactionTX(&ts, &account, &stat, store.Actor{UserID: user.ID})
 	require.NoError(t, err)
 	require.NotEmpty(t, stat)
 
@@ -80,7 +80,7 @@ func TestModels_UpdateTransactionTX(t *testing.T) {
 			expectedEarning = stat.Earning
 		}
 
-		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat)
+		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat, store.Actor{UserID: oldTS.UserID})
 
 		require.NoError(t, err)
 		require.NotEmpty(t, newTS)
@@ -111,7 +111,7 @@ func TestModels_UpdateTransactionTX(t *testing.T) {
 			expectedSpending = stat.Spending - newTS.Amount
 		}
 
-		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat)
+		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat, store.Actor{UserID: oldTS.UserID})
 
 		require.NoError(t, err)
 		require.NotEmpty(t, newTS)
@@ -148,7 +148,7 @@ func TestModels_UpdateTransactionTX(t *testing.T) {
 			expectedSpending -= newTS.Amount
 		}
 
-		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat)
+		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat, store.Actor{UserID: oldTS.UserID})
 
 		require.NoError(t, err)
 		require.NotEmpty(t, newTS)
@@ -178,7 +178,7 @@ func TestModels_UpdateTransactionTX(t *testing.T) {
 			expectedSpending = stat.Spending - newTS.Amount
 		}
 
-		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat)
+		err := testModels.UpdateTransactionTX(&newTS, *oldTS, account, stat, store.Actor{UserID: oldTS.UserID})
 
 		require.NoError(t, err)
 		require.NotEmpty(t, newTS)
@@ -204,7 +204,7 @@ func TestModels_DeleteTransactionTX(t *testing.T) {
 		expectedSpending = stat.Spending - ts.Amount
 	}
 
-	err := testModels.DeleteTransactionTX(ts, account, stat)
+	err := testModels.DeleteTransactionTX(ts, account, stat, store.Actor{UserID: ts.UserID})
 	require.NoError(t, err)
 	require.NotEmpty(t, stat)
 
@@ -213,3 +213,37 @@ func TestModels_DeleteTransactionTX(t *testing.T) {
 	require.Equal(t, account.TotalExpense, float64(0))
 	require.Equal(t, account.TotalIncome, float64(0))
 }
+
+func TestModels_RestoreTransactionTX(t *testing.T) {
+	ts, account, stat := createRandomTX(t)
+	actor := store.Actor{UserID: ts.UserID}
+
+	err := testModels.DeleteTransactionTX(ts, account, stat, actor)
+	require.NoError(t, err)
+
+	deleted, err := testModels.Transactions.GetDeleted(ts.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+
+	err = testModels.RestoreTransactionTX(deleted, account, stat, actor)
+	require.NoError(t, err)
+	require.Nil(t, deleted.DeletedAt)
+
+	if ts.Type == "income" {
+		require.Equal(t, ts.Amount, stat.Earning)
+		require.Equal(t, ts.Amount, account.TotalIncome)
+	} else {
+		require.Equal(t, ts.Amount, stat.Spending)
+		require.Equal(t, ts.Amount, account.TotalExpense)
+	}
+
+	restored, err := testModels.Transactions.Get(ts.ID)
+	require.NoError(t, err)
+	require.Equal(t, ts.ID, restored.ID)
+
+	t.Run("not deleted case for restore transaction", func(t *testing.T) {
+		err := testModels.RestoreTransactionTX(deleted, account, stat, actor)
+		require.Error(t, err)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+const recoveryCodeCount = 10
+
+// TwoFactor is the TOTP secret of the user. The secret is pending
+// until the user verifies a code generated from it.
+type TwoFactor struct {
+	UserID    int64     `json:"-"`
+	Secret    string    `json:"-"`
+	Enabled   bool      `json:"enabled"`
+	LastStep  int64     `json:"-"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type twoFactorModel struct {
+	DB DBTX
+}
+
+func (m *twoFactorModel) Get(userID int64) (*TwoFactor, error) {
+	query := `SELECT user_id, secret, enabled, last_step, created_at
+	FROM two_factors
+	WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var tf TwoFactor
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(
+		&tf.UserID,
+		&tf.Secret,
+		&tf.Enabled,
+		&tf.LastStep,
+		&tf.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &tf, nil
+}
+
+// SetPending replaces the pending secret of the user. It returns
+// ErrTwoFactorEnabled if the two-factor authentication is enabled.
+func (m *twoFactorModel) SetPending(tf *TwoFactor) error {
+	query := `INSERT INTO two_factors (user_id, secret)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE
+	SET secret = EXCLUDED.secret, last_step = 0, created_at = now()
+	WHERE two_factors.enabled = false
+	RETURNING enabled, last_step, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, tf.UserID, tf.Secret).Scan(&tf.Enabled, &tf.LastStep, &tf.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTwoFactorEnabled
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UseStep records the time step of a verified code. It returns ErrRecordNotFound
+// if a code of the same or a later step was used before, so a code can't be replayed.
+func (m *twoFactorModel) UseStep(userID int64, step int64) error {
+	query := `UPDATE two_factors SET last_step = $2
+	WHERE user_id = $1 AND last_step < $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (m *twoFactorModel) Enable(userID int64) error {
+	query := `UPDATE two_factors SET enabled = true WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+
+	return err
+}
+
+func (m *twoFactorModel) Delete(userID int64) error {
+	query := `DELETE FROM two_factors WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+
+	return err
+}
+
+type recoveryCodeModel struct {
+	DB DBTX
+}
+
+// normalizeRecoveryCode lets the users type the codes without the dash or in upper case.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// New replaces the recovery codes of the user and returns the plaintext of the new ones.
+func (m *recoveryCodeModel) New(userID int64) ([]string, error) {
+	if err := m.DeleteAllForUser(userID); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO recovery_codes (hash, user_id) VALUES ($1, $2)`
+
+	codes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		random, err := randomString(5)
+		if err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(random)
+		hash := sha256.Sum256([]byte(code))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err = m.DB.ExecContext(ctx, query, hash[:], userID)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+
+		codes[i] = code[:4] + "-" + code[4:]
+	}
+
+	return codes, nil
+}
+
+// Use marks the recovery code as used. It returns ErrRecordNotFound
+// if the code does not exist or is already used.
+func (m *recoveryCodeModel) Use(userID int64, code string) error {
+	hash := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+
+	query := `UPDATE recovery_codes SET used_at = now()
+	WHERE hash = $1 AND user_id = $2 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, hash[:], userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// CountUnused returns the number of the recovery codes the user can still use.
+func (m *recoveryCodeModel) CountUnused(userID int64) (int, error) {
+	query := `SELECT count(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var count int
+
+	err := m.DB.QueryRowContext(ctx, query, userID).Scan(&count)
+
+	return count, err
+}
+
+func (m *recoveryCodeModel) DeleteAllForUser(userID int64) error {
+	query := `DELETE FROM recovery_codes WHERE user_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, userID)
+
+	return err
+}
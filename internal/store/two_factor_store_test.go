@@ -0,0 +1,91 @@
+package store_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/internal/store"
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func createPendingTwoFactor(t *testing.T) *store.TwoFactor {
+	user := createRandomUser(t)
+
+	secret, err := auth.NewTOTPSecret()
+	require.NoError(t, err)
+
+	tf := &store.TwoFactor{UserID: user.ID, Secret: secret}
+
+	err = testModels.TwoFactors.SetPending(tf)
+	require.NoError(t, err)
+	require.False(t, tf.Enabled)
+
+	return tf
+}
+
+func TestModels_EnableTwoFactorTX(t *testing.T) {
+	tf := createPendingTwoFactor(t)
+	step := auth.TOTPStep(time.Now())
+
+	codes, err := testModels.EnableTwoFactorTX(tf.UserID, step)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	got, err := testModels.TwoFactors.Get(tf.UserID)
+	require.NoError(t, err)
+	require.True(t, got.Enabled)
+	require.Equal(t, step, got.LastStep)
+
+	t.Run("enrollment case for enabled two factor", func(t *testing.T) {
+		err := testModels.TwoFactors.SetPending(&store.TwoFactor{UserID: tf.UserID, Secret: "OTHER"})
+		require.ErrorIs(t, err, store.ErrTwoFactorEnabled)
+	})
+
+	t.Run("replay case for use step", func(t *testing.T) {
+		err := testModels.TwoFactors.UseStep(tf.UserID, step)
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+		err = testModels.TwoFactors.UseStep(tf.UserID, step+1)
+		require.NoError(t, err)
+	})
+
+	t.Run("one time case for recovery codes", func(t *testing.T) {
+		err := testModels.RecoveryCodes.Use(tf.UserID, strings.ToUpper(codes[0]))
+		require.NoError(t, err)
+
+		err = testModels.RecoveryCodes.Use(tf.UserID, codes[0])
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+		count, err := testModels.RecoveryCodes.CountUnused(tf.UserID)
+		require.NoError(t, err)
+		require.Equal(t, 9, count)
+	})
+
+	t.Run("regenerate case for recovery codes", func(t *testing.T) {
+		newCodes, err := testModels.RegenerateRecoveryCodesTX(tf.UserID)
+		require.NoError(t, err)
+		require.Len(t, newCodes, 10)
+
+		err = testModels.RecoveryCodes.Use(tf.UserID, codes[1])
+		require.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+}
+
+func TestModels_DisableTwoFactorTX(t *testing.T) {
+	tf := createPendingTwoFactor(t)
+
+	_, err := testModels.EnableTwoFactorTX(tf.UserID, 1)
+	require.NoError(t, err)
+
+	err = testModels.DisableTwoFactorTX(tf.UserID)
+	require.NoError(t, err)
+
+	_, err = testModels.TwoFactors.Get(tf.UserID)
+	require.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	count, err := testModels.RecoveryCodes.CountUnused(tf.UserID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
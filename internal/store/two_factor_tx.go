@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// EnableTwoFactorTX enables the pending secret which is verified with the code
+// of the step and returns the recovery codes of the user.
+func (m *Models) EnableTwoFactorTX(userID int64, step int64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.TwoFactors.UseStep(userID, step); err != nil {
+		return nil, err
+	}
+
+	if err := txModels.TwoFactors.Enable(userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := txModels.RecoveryCodes.New(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (m *Models) RegenerateRecoveryCodesTX(userID int64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	codes, err := txModels.RecoveryCodes.New(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (m *Models) DisableTwoFactorTX(userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txModels := NewModelsWithTX(tx)
+
+	if err := txModels.TwoFactors.Delete(userID); err != nil {
+		return err
+	}
+
+	if err := txModels.RecoveryCodes.DeleteAllForUser(userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -14,6 +14,7 @@ type User struct {
 	ID          int64         `json:"id"`
 	Name        string        `json:"name"`
 	Email       string        `json:"email"`
+	Currency    string        `json:"currency"`
 	Password    auth.Password `json:"-"`
 	CreatedAt   time.Time     `json:"createdAt"`
 	IsActivated bool          `json:"isActivated"`
@@ -31,16 +32,16 @@ type userModel struct {
 }
 
 func (m *userModel) Insert(user *User) error {
-	query := `INSERT INTO users (name, email, hashed_password, is_activated)
-VALUES ($1, $2, $3, $4)
-RETURNING id, created_at, version`
+	query := `INSERT INTO users (name, email, hashed_password, is_activated, currency)
+VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'USD'))
+RETURNING id, created_at, currency, version`
 
-	args := []interface{}{user.Name, user.Email, user.Password.Hashed, user.IsActivated}
+	args := []interface{}{user.Name, user.Email, user.Password.Hashed, user.IsActivated, user.Currency}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Currency, &user.Version)
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
@@ -54,7 +55,7 @@ RETURNING id, created_at, version`
 }
 
 func (m *userModel) Get(id int64) (*User, error) {
-	query := `SELECT id, created_at, name, email, hashed_password, is_activated, version
+	query := `SELECT id, created_at, name, email, currency, hashed_password, is_activated, version
 FROM users
 WHERE id = $1`
 
@@ -68,6 +69,7 @@ WHERE id = $1`
 		&user.CreatedAt,
 		&user.Name,
 		&user.Email,
+		&user.Currency,
 		&user.Password.Hashed,
 		&user.IsActivated,
 		&user.Version,
@@ -84,7 +86,7 @@ WHERE id = $1`
 }
 
 func (m *userModel) GetByEmail(email string) (*User, error) {
-	query := `SELECT id, created_at, name, email, hashed_password, is_activated, version
+	query := `SELECT id, created_at, name, email, currency, hashed_password, is_activated, version
 FROM users
 WHERE email = $1`
 
@@ -98,6 +100,7 @@ WHERE email = $1`
 		&user.CreatedAt,
 		&user.Name,
 		&user.Email,
+		&user.Currency,
 		&user.Password.Hashed,
 		&user.IsActivated,
 		&user.Version,
@@ -115,8 +118,8 @@ WHERE email = $1`
 
 func (m *userModel) Update(user *User) error {
 	query := `UPDATE users
-SET name = $1, email=$2, hashed_password=$3, is_activated=$4, version=version+1
-WHERE id=$5 AND version=$6
+SET name = $1, email=$2, hashed_password=$3, is_activated=$4, currency=$5, version=version+1
+WHERE id=$6 AND version=$7
 RETURNING version`
 
 	args := []interface{}{
@@ -124,6 +127,7 @@ RETURNING version`
 		user.Email,
 		user.Password.Hashed,
 		user.IsActivated,
+		user.Currency,
 		user.ID,
 		user.Version,
 	}
@@ -149,7 +153,7 @@ RETURNING version`
 func (m *userModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error) {
 	tokenHash := sha256.Sum256([]byte(tokenPlaintext))
 
-	query := `SELECT users.id, users.created_at, users.name, users.email, users.hashed_password, users.is_activated, users.version
+	query := `SELECT users.id, users.created_at, users.name, users.email, users.currency, users.hashed_password, users.is_activated, users.version
 	FROM users
 	INNER JOIN tokens
 	ON users.id = tokens.user_id
@@ -167,6 +171,7 @@ func (m *userModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error
 		&user.CreatedAt,
 		&user.Name,
 		&user.Email,
+		&user.Currency,
 		&user.Password.Hashed,
 		&user.IsActivated,
 		&user.Version,
@@ -185,8 +190,8 @@ func (m *userModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error
 func (m *userModel) GetAccounts(userID int64) ([]*Account, error) {
 	query := `SELECT a.id, a.owner_id, a.title, a.description, a.total_income, a.total_expense, a.currency, a.created_at, a.version
 	FROM users_accounts u
-	LEFT JOIN accounts a ON u.account_id = a.id
-	WHERE u.user_id = $1`
+	INNER JOIN accounts a ON u.account_id = a.id
+	WHERE u.user_id = $1 AND a.deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -159,7 +159,7 @@ func TestUserModel_GetForToken(t *testing.T) {
 func TestUserModel_GetAccounts(t *testing.T) {
 	account := createRandomAccount(t)
 
-	err := testModels.Accounts.AddUser(account.OwnerID, account.ID)
+	err := testModels.Accounts.AddUser(account.OwnerID, account.ID, store.RoleOwner)
 	require.NoError(t, err)
 
 	accounts, err := testModels.Users.GetAccounts(account.OwnerID)
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'USD';
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    currency text PRIMARY KEY,
+    rate double precision NOT NULL,
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT now()
+);
+
+INSERT INTO exchange_rates (currency, rate) VALUES ('USD', 1) ON CONFLICT DO NOTHING;
\ No newline at end of file
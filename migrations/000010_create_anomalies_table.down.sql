@@ -0,0 +1 @@
+DROP TABLE IF EXISTS anomalies;
\ No newline at end of file
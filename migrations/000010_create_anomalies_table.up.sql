@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS anomalies (
+    id bigserial PRIMARY KEY,
+    account_id bigint NOT NULL REFERENCES accounts ON DELETE CASCADE,
+    transaction_id bigint NOT NULL REFERENCES transactions ON DELETE CASCADE,
+    kind text NOT NULL,
+    message text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT now(),
+    dismissed_at timestamp(0) with time zone,
+    UNIQUE (transaction_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS anomalies_account_id_idx ON anomalies (account_id);
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- The members before the roles could edit and delete any transaction of the account,
+-- so they keep doing it as editors. The new members get their roles from the invitations.
+ALTER TABLE users_accounts ADD COLUMN IF NOT EXISTS role text NOT NULL DEFAULT 'editor';
+
+UPDATE users_accounts SET role = 'owner'
+FROM accounts
+WHERE accounts.id = users_accounts.account_id AND accounts.owner_id = users_accounts.user_id;
+
+ALTER TABLE users_accounts ALTER COLUMN role DROP DEFAULT;
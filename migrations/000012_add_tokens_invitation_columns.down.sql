@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tokens_email_idx;
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS created_at;
+ALTER TABLE tokens DROP COLUMN IF EXISTS role;
+ALTER TABLE tokens DROP COLUMN IF EXISTS email;
+ALTER TABLE tokens DROP COLUMN IF EXISTS account_id;
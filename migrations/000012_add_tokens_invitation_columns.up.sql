@@ -0,0 +1,6 @@
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS account_id bigint REFERENCES accounts ON DELETE CASCADE;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS email text;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS role text;
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS tokens_email_idx ON tokens (email);
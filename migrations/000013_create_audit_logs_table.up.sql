@@ -0,0 +1,16 @@
+-- The account and the actor are not foreign keys so that the log is kept
+-- after they are deleted.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id bigserial PRIMARY KEY,
+    account_id bigint NOT NULL,
+    actor_id bigint NOT NULL,
+    action text NOT NULL,
+    entity text NOT NULL,
+    entity_id bigint NOT NULL,
+    before jsonb,
+    after jsonb,
+    ip text NOT NULL DEFAULT '',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_account_id_idx ON audit_logs (account_id, id DESC);
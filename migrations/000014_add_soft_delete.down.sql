@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS transactions_deleted_at_idx;
+DROP INDEX IF EXISTS accounts_deleted_at_idx;
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE accounts DROP COLUMN IF EXISTS deleted_at;
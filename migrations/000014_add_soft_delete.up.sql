@@ -0,0 +1,5 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deleted_at timestamp(0) with time zone;
+
+CREATE INDEX IF NOT EXISTS accounts_deleted_at_idx ON accounts (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS transactions_deleted_at_idx ON transactions (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS transaction_revisions (
+    id bigserial PRIMARY KEY,
+    transaction_id bigint NOT NULL REFERENCES transactions ON DELETE CASCADE,
+    version integer NOT NULL,
+    editor_id bigint NOT NULL,
+    type text NOT NULL,
+    title text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    tags text [],
+    amount real NOT NULL,
+    payday date NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT now(),
+    UNIQUE (transaction_id, version)
+);
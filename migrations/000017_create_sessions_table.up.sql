@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users ON DELETE CASCADE,
+    family text NOT NULL UNIQUE,
+    device text NOT NULL,
+    ip text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT now(),
+    last_used_at timestamp(0) with time zone NOT NULL DEFAULT now(),
+    revoked_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id) WHERE revoked_at IS NULL;
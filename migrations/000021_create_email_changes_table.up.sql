@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS email_changes (
+    user_id bigint PRIMARY KEY REFERENCES users ON DELETE CASCADE,
+    old_email text NOT NULL,
+    new_email text NOT NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT now(),
+    confirmed_at timestamp(0) with time zone
+);
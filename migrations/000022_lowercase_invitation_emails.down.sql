@@ -0,0 +1 @@
+-- The original capitalization of the emails is not kept.
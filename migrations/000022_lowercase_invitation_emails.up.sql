@@ -0,0 +1 @@
+UPDATE tokens SET email = lower(email) WHERE email IS NOT NULL;
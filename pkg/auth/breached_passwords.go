@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedPasswords is a local copy of the breached password hashes in the k-anonymity
+// range format of Have I Been Pwned. The directory has a file for every 5 character
+// prefix of the SHA-1 hashes, such as 21BD1.txt, with a SUFFIX:COUNT line for every hash.
+type BreachedPasswords struct {
+	dir string
+}
+
+func NewBreachedPasswords(dir string) (*BreachedPasswords, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, errors.New("breached passwords path must be a directory")
+	}
+
+	return &BreachedPasswords{dir: dir}, nil
+}
+
+// Contains reports whether the password is in the list. A missing
+// range file means that no password of the range is breached.
+func (b *BreachedPasswords) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	prefix, suffix := hash[:5], hash[5:]
+
+	f, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		parts := strings.SplitN(line, ":", 2)
+
+		// The padding entries of the range responses have a zero count.
+		if strings.EqualFold(parts[0], suffix) && (len(parts) == 1 || parts[1] != "0") {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
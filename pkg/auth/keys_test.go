@@ -0,0 +1,172 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir string, id string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	err = os.WriteFile(filepath.Join(dir, id+".pem"), data, 0600)
+	require.NoError(t, err)
+}
+
+func writePublicKey(t *testing.T, dir string, id string, key interface{}) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	err = os.WriteFile(filepath.Join(dir, id+".pem"), data, 0600)
+	require.NoError(t, err)
+}
+
+func loadKeys(t *testing.T, dir string) []*auth.Key {
+	keys, err := auth.LoadKeys(dir)
+	require.NoError(t, err)
+	return keys
+}
+
+func loadKeySet(t *testing.T, dir string, signingKeyID string) *auth.KeySet {
+	ks, err := auth.NewKeySet(signingKeyID, loadKeys(t, dir)...)
+	require.NoError(t, err)
+
+	return ks
+}
+
+func TestJWTMakerWithKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKey(t, dir, "rsa-1", rsaKey)
+	writeKey(t, dir, "ed-1", edKey)
+
+	for _, kid := range []string{"rsa-1", "ed-1"} {
+		t.Run("success case for "+kid, func(t *testing.T) {
+			maker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, kid), "goExpense", "api")
+
+			token, err := maker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+			require.NoError(t, err)
+			require.Equal(t, kid, parsed.Header["kid"])
+
+			claims := parsed.Claims.(jwt.MapClaims)
+			require.Equal(t, "goExpense", claims["iss"])
+			require.Equal(t, "7", claims["sub"])
+			require.Equal(t, []interface{}{"api"}, claims["aud"])
+			require.NotEmpty(t, claims["jti"])
+			require.NotEmpty(t, claims["exp"])
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, int64(7), payload.UserID)
+			require.Equal(t, int64(3), payload.SessionID)
+		})
+	}
+
+	t.Run("rotation case for verify token", func(t *testing.T) {
+		oldMaker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, "rsa-1"), "goExpense", "api")
+
+		token, err := oldMaker.CreateToken(7, 3, time.Hour)
+		require.NoError(t, err)
+
+		rotated := t.TempDir()
+		writePublicKey(t, rotated, "rsa-1", &rsaKey.PublicKey)
+		writeKey(t, rotated, "ed-1", edKey)
+
+		newMaker := auth.NewJWTMakerWithKeys(loadKeySet(t, rotated, "ed-1"), "goExpense", "api")
+
+		_, err = newMaker.VerifyToken(token)
+		require.NoError(t, err)
+
+		_, err = auth.NewKeySet("rsa-1", loadKeys(t, rotated)...)
+		require.Error(t, err)
+	})
+
+	t.Run("unknown key case for verify token", func(t *testing.T) {
+		other := t.TempDir()
+		_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		writeKey(t, other, "ed-2", otherKey)
+
+		otherMaker := auth.NewJWTMakerWithKeys(loadKeySet(t, other, "ed-2"), "goExpense", "api")
+
+		token, err := otherMaker.CreateToken(7, 3, time.Hour)
+		require.NoError(t, err)
+
+		maker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, "ed-1"), "goExpense", "api")
+
+		_, err = maker.VerifyToken(token)
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("audience case for verify token", func(t *testing.T) {
+		otherMaker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, "ed-1"), "goExpense", "other")
+
+		token, err := otherMaker.CreateToken(7, 3, time.Hour)
+		require.NoError(t, err)
+
+		maker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, "ed-1"), "goExpense", "api")
+
+		_, err = maker.VerifyToken(token)
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("algorithm confusion case for verify token", func(t *testing.T) {
+		// An HS256 token signed with the public key as the secret.
+		publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		require.NoError(t, err)
+
+		payload, err := auth.NewPayload(7, 3, time.Hour)
+		require.NoError(t, err)
+
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+		forged.Header["kid"] = "rsa-1"
+
+		token, err := forged.SignedString(publicDER)
+		require.NoError(t, err)
+
+		maker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, "rsa-1"), "", "")
+
+		_, err = maker.VerifyToken(token)
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("jwks case", func(t *testing.T) {
+		maker := auth.NewJWTMakerWithKeys(loadKeySet(t, dir, "rsa-1"), "", "")
+
+		jwks := maker.JWKS()
+		require.Len(t, jwks.Keys, 2)
+
+		require.Equal(t, "ed-1", jwks.Keys[0].KeyID)
+		require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+		require.Equal(t, "EdDSA", jwks.Keys[0].Algorithm)
+		require.NotEmpty(t, jwks.Keys[0].X)
+
+		require.Equal(t, "rsa-1", jwks.Keys[1].KeyID)
+		require.Equal(t, "RSA", jwks.Keys[1].KeyType)
+		require.Equal(t, "RS256", jwks.Keys[1].Algorithm)
+		require.Equal(t, "AQAB", jwks.Keys[1].E)
+	})
+}
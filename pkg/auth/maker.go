@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+)
+
+// Maker creates and verifies the access tokens. The handlers only depend
+// on this interface so that the token format can be changed by the config.
+type Maker interface {
+	CreateToken(userID int64, sessionID int64, duration time.Duration) (string, error)
+	VerifyToken(token string) (*Payload, error)
+}
+
+// KeyPublisher is implemented by the makers whose tokens can be verified
+// by the other services with the published public keys.
+type KeyPublisher interface {
+	JWKS() JWKS
+}
+
+const (
+	TokenTypeJWT          = "jwt"
+	TokenTypePASETOLocal  = "paseto-local"
+	TokenTypePASETOPublic = "paseto-public"
+)
+
+// NewMaker returns the maker of the token type. The symmetric key is used by the
+// HS256 JWTs and the local PASETOs, the key set by the other token types.
+func NewMaker(tokenType string, symmetricKey string, keys *KeySet, issuer string, audience string) (Maker, error) {
+	switch tokenType {
+	case "", TokenTypeJWT:
+		if keys == nil {
+			return NewJWTMaker(symmetricKey)
+		}
+		return NewJWTMakerWithKeys(keys, issuer, audience), nil
+	case TokenTypePASETOLocal:
+		return NewPASETOLocalMaker(symmetricKey, issuer, audience)
+	case TokenTypePASETOPublic:
+		if keys == nil {
+			return nil, fmt.Errorf("token type %q requires the keys", tokenType)
+		}
+		return NewPASETOPublicMaker(keys, issuer, audience)
+	default:
+		return nil, fmt.Errorf("unknown token type %q", tokenType)
+	}
+}
@@ -0,0 +1,222 @@
+package auth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/nebisin/goExpense/pkg/random"
+	"github.com/stretchr/testify/require"
+)
+
+// newMakers returns a maker of every implementation. Every call returns
+// the makers with new keys so that the makers of two calls differ.
+func newMakers(t *testing.T) map[string]auth.Maker {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaDir := t.TempDir()
+	writeKey(t, rsaDir, "rsa-1", rsaKey)
+
+	edDir := t.TempDir()
+	writeKey(t, edDir, "ed-1", edKey)
+
+	secret := random.String(32)
+
+	jwtMaker, err := auth.NewMaker(auth.TokenTypeJWT, secret, nil, "", "")
+	require.NoError(t, err)
+
+	pasetoLocalMaker, err := auth.NewMaker(auth.TokenTypePASETOLocal, secret, nil, "goExpense", "api")
+	require.NoError(t, err)
+
+	pasetoPublicMaker, err := auth.NewMaker(auth.TokenTypePASETOPublic, "", loadKeySet(t, edDir, "ed-1"), "goExpense", "api")
+	require.NoError(t, err)
+
+	return map[string]auth.Maker{
+		"jwt HS256":     jwtMaker,
+		"jwt RS256":     auth.NewJWTMakerWithKeys(loadKeySet(t, rsaDir, "rsa-1"), "goExpense", "api"),
+		"jwt EdDSA":     auth.NewJWTMakerWithKeys(loadKeySet(t, edDir, "ed-1"), "goExpense", "api"),
+		"paseto local":  pasetoLocalMaker,
+		"paseto public": pasetoPublicMaker,
+	}
+}
+
+func TestMaker(t *testing.T) {
+	makers := newMakers(t)
+	otherMakers := newMakers(t)
+
+	for name, maker := range makers {
+		maker := maker
+		otherMaker := otherMakers[name]
+
+		t.Run("success case for "+name, func(t *testing.T) {
+			userID := random.Int(2, 6)
+			sessionID := random.Int(1, 1000)
+
+			token, err := maker.CreateToken(userID, sessionID, time.Hour)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload.ID)
+			require.Equal(t, userID, payload.UserID)
+			require.Equal(t, sessionID, payload.SessionID)
+			require.WithinDuration(t, time.Now(), payload.IssuedAt, 2*time.Second)
+			require.WithinDuration(t, time.Now().Add(time.Hour), payload.ExpiredAt, 2*time.Second)
+		})
+
+		t.Run("unique token case for "+name, func(t *testing.T) {
+			first, err := maker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			second, err := maker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			require.NotEqual(t, first, second)
+		})
+
+		t.Run("expired token case for "+name, func(t *testing.T) {
+			token, err := maker.CreateToken(7, 3, -time.Minute)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.ErrorIs(t, err, auth.ErrExpiredToken)
+			require.Nil(t, payload)
+		})
+
+		t.Run("tampered token case for "+name, func(t *testing.T) {
+			token, err := maker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			// Flip a character in the middle of the token.
+			i := len(token) / 2
+			replacement := "A"
+			if token[i] == 'A' {
+				replacement = "B"
+			}
+			tampered := token[:i] + replacement + token[i+1:]
+
+			payload, err := maker.VerifyToken(tampered)
+			require.ErrorIs(t, err, auth.ErrInvalidToken)
+			require.Nil(t, payload)
+		})
+
+		t.Run("truncated token case for "+name, func(t *testing.T) {
+			token, err := maker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token[:len(token)-10])
+			require.ErrorIs(t, err, auth.ErrInvalidToken)
+			require.Nil(t, payload)
+		})
+
+		t.Run("other key case for "+name, func(t *testing.T) {
+			token, err := otherMaker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.ErrorIs(t, err, auth.ErrInvalidToken)
+			require.Nil(t, payload)
+		})
+
+		t.Run("invalid token case for "+name, func(t *testing.T) {
+			for _, token := range []string{"", "token", "v4.local.", "v4.public.", "a.b.c"} {
+				payload, err := maker.VerifyToken(token)
+				require.ErrorIs(t, err, auth.ErrInvalidToken, token)
+				require.Nil(t, payload)
+			}
+		})
+
+		t.Run("cross implementation case for "+name, func(t *testing.T) {
+			token, err := maker.CreateToken(7, 3, time.Hour)
+			require.NoError(t, err)
+
+			for otherName, other := range makers {
+				if otherName == name {
+					continue
+				}
+
+				_, err := other.VerifyToken(token)
+				require.ErrorIs(t, err, auth.ErrInvalidToken, otherName)
+			}
+		})
+	}
+}
+
+func TestPASETOMaker(t *testing.T) {
+	secret := random.String(32)
+
+	t.Run("local token case", func(t *testing.T) {
+		maker, err := auth.NewPASETOLocalMaker(secret, "goExpense", "api")
+		require.NoError(t, err)
+
+		token, err := maker.CreateToken(7, 3, time.Hour)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(token, "v4.local."))
+
+		// The claims of the local tokens are encrypted.
+		require.NotContains(t, token, "eyJ")
+	})
+
+	t.Run("public token case", func(t *testing.T) {
+		_, edKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		writeKey(t, dir, "ed-1", edKey)
+
+		maker, err := auth.NewPASETOPublicMaker(loadKeySet(t, dir, "ed-1"), "goExpense", "api")
+		require.NoError(t, err)
+
+		token, err := maker.CreateToken(7, 3, time.Hour)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(token, "v4.public."))
+		require.Len(t, strings.Split(token, "."), 4)
+	})
+
+	t.Run("key size case", func(t *testing.T) {
+		_, err := auth.NewPASETOLocalMaker(random.String(16), "", "")
+		require.Error(t, err)
+	})
+
+	t.Run("rsa key case", func(t *testing.T) {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		dir := t.TempDir()
+		writeKey(t, dir, "rsa-1", rsaKey)
+
+		_, err = auth.NewPASETOPublicMaker(loadKeySet(t, dir, "rsa-1"), "", "")
+		require.Error(t, err)
+	})
+
+	t.Run("audience case", func(t *testing.T) {
+		otherMaker, err := auth.NewPASETOLocalMaker(secret, "goExpense", "other")
+		require.NoError(t, err)
+
+		token, err := otherMaker.CreateToken(7, 3, time.Hour)
+		require.NoError(t, err)
+
+		maker, err := auth.NewPASETOLocalMaker(secret, "goExpense", "api")
+		require.NoError(t, err)
+
+		_, err = maker.VerifyToken(token)
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+}
+
+func TestNewMaker(t *testing.T) {
+	_, err := auth.NewMaker("unknown", random.String(32), nil, "", "")
+	require.Error(t, err)
+
+	_, err = auth.NewMaker(auth.TokenTypePASETOPublic, random.String(32), nil, "", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,370 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	pasetoLocalHeader  = "v4.local."
+	pasetoPublicHeader = "v4.public."
+
+	pasetoKeySize   = 32
+	pasetoNonceSize = 32
+	pasetoMACSize   = 32
+)
+
+// PASETOMaker creates the version 4 PASETOs. The local tokens are encrypted with
+// a symmetric key and the public tokens are signed with an Ed25519 key.
+type PASETOMaker struct {
+	localKey []byte
+	keys     *KeySet
+	issuer   string
+	audience string
+}
+
+// NewPASETOLocalMaker returns a maker which encrypts the tokens with the key. The issuer
+// and the audience are set on the tokens and required from the verified ones unless they are empty.
+func NewPASETOLocalMaker(symmetricKey string, issuer string, audience string) (*PASETOMaker, error) {
+	if len(symmetricKey) != pasetoKeySize {
+		return nil, fmt.Errorf("key size must be exactly %d characters", pasetoKeySize)
+	}
+
+	return &PASETOMaker{localKey: []byte(symmetricKey), issuer: issuer, audience: audience}, nil
+}
+
+// NewPASETOPublicMaker returns a maker which signs the tokens with the signing key of the set
+// and verifies them with any key of the set. All the keys of the set must be Ed25519 keys.
+func NewPASETOPublicMaker(keys *KeySet, issuer string, audience string) (*PASETOMaker, error) {
+	for id, key := range keys.keys {
+		if _, ok := key.public.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("key %q must be an Ed25519 key", id)
+		}
+	}
+
+	return &PASETOMaker{keys: keys, issuer: issuer, audience: audience}, nil
+}
+
+// pasetoClaims encodes the times in the RFC 3339 format the PASETO claims use.
+type pasetoClaims struct {
+	ID        string `json:"jti"`
+	Issuer    string `json:"iss,omitempty"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud,omitempty"`
+	UserID    int64  `json:"user_id"`
+	SessionID int64  `json:"session_id,omitempty"`
+	IssuedAt  string `json:"iat"`
+	ExpiredAt string `json:"exp"`
+}
+
+// pasetoFooter identifies the key of the public tokens.
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+func (maker *PASETOMaker) CreateToken(userID int64, sessionID int64, duration time.Duration) (string, error) {
+	payload, err := NewPayload(userID, sessionID, duration)
+	if err != nil {
+		return "", err
+	}
+
+	message, err := json.Marshal(pasetoClaims{
+		ID:        payload.ID,
+		Issuer:    maker.issuer,
+		Subject:   payload.Subject,
+		Audience:  maker.audience,
+		UserID:    payload.UserID,
+		SessionID: payload.SessionID,
+		IssuedAt:  payload.IssuedAt.Format(time.RFC3339),
+		ExpiredAt: payload.ExpiredAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if maker.localKey != nil {
+		return pasetoEncrypt(maker.localKey, message)
+	}
+
+	key := maker.keys.signing
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+
+	return pasetoSign(key.private.(ed25519.PrivateKey), message, footer, nil), nil
+}
+
+func (maker *PASETOMaker) VerifyToken(token string) (*Payload, error) {
+	var message []byte
+	var err error
+
+	if maker.localKey != nil {
+		message, err = pasetoDecrypt(maker.localKey, token)
+	} else {
+		message, err = maker.verifyPublic(token)
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims pasetoClaims
+	if err := json.Unmarshal(message, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	issuedAt, err := time.Parse(time.RFC3339, claims.IssuedAt)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	expiredAt, err := time.Parse(time.RFC3339, claims.ExpiredAt)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{
+		ID:        claims.ID,
+		Issuer:    claims.Issuer,
+		Subject:   claims.Subject,
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		IssuedAt:  issuedAt,
+		ExpiredAt: expiredAt,
+	}
+
+	if claims.Audience != "" {
+		payload.Audience = []string{claims.Audience}
+	}
+
+	if err := payload.Valid(); err != nil {
+		return nil, err
+	}
+
+	if maker.issuer != "" && payload.Issuer != maker.issuer {
+		return nil, ErrInvalidToken
+	}
+
+	if maker.audience != "" && !payload.HasAudience(maker.audience) {
+		return nil, ErrInvalidToken
+	}
+
+	return payload, nil
+}
+
+func (maker *PASETOMaker) verifyPublic(token string) ([]byte, error) {
+	_, footer, err := splitPASETO(pasetoPublicHeader, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var f pasetoFooter
+	if err := json.Unmarshal(footer, &f); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	key, err := maker.keys.Get(f.KeyID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return pasetoVerify(key.public.(ed25519.PublicKey), token, nil)
+}
+
+// pae is the pre-authentication encoding of the PASETO specification.
+func pae(pieces ...[]byte) []byte {
+	out := make([]byte, 8)
+	binary.LittleEndian.PutUint64(out, uint64(len(pieces)))
+
+	for _, piece := range pieces {
+		length := make([]byte, 8)
+		binary.LittleEndian.PutUint64(length, uint64(len(piece)))
+		out = append(out, length...)
+		out = append(out, piece...)
+	}
+
+	return out
+}
+
+// splitPASETO returns the decoded body and footer of the token with the header.
+func splitPASETO(header string, token string) ([]byte, []byte, error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, header), ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	var footer []byte
+	if len(parts) == 2 {
+		if footer, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+			return nil, nil, ErrInvalidToken
+		}
+	}
+
+	return body, footer, nil
+}
+
+func joinPASETO(header string, body []byte, footer []byte) string {
+	token := header + base64.RawURLEncoding.EncodeToString(body)
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token
+}
+
+// pasetoLocalKeys derives the encryption key, the counter nonce and the
+// authentication key of a local token from the key and the nonce.
+func pasetoLocalKeys(key []byte, nonce []byte) ([]byte, []byte, []byte, error) {
+	h, err := blake2b.New(56, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h.Write([]byte("paseto-encryption-key"))
+	h.Write(nonce)
+	tmp := h.Sum(nil)
+
+	h, err = blake2b.New(32, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	h.Write([]byte("paseto-auth-key-for-aead"))
+	h.Write(nonce)
+
+	return tmp[:32], tmp[32:], h.Sum(nil), nil
+}
+
+func pasetoMAC(authKey []byte, preAuth []byte) ([]byte, error) {
+	h, err := blake2b.New(pasetoMACSize, authKey)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(preAuth)
+	return h.Sum(nil), nil
+}
+
+func pasetoEncrypt(key []byte, message []byte) (string, error) {
+	nonce := make([]byte, pasetoNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return pasetoSeal(key, nonce, message, nil, nil)
+}
+
+// pasetoSeal encrypts the message with the nonce. The footer is authenticated and appended
+// to the token, and the implicit assertion is authenticated without being appended.
+func pasetoSeal(key []byte, nonce []byte, message []byte, footer []byte, implicit []byte) (string, error) {
+	encKey, counterNonce, authKey, err := pasetoLocalKeys(key, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext := make([]byte, len(message))
+	cipher.XORKeyStream(ciphertext, message)
+
+	mac, err := pasetoMAC(authKey, pae([]byte(pasetoLocalHeader), nonce, ciphertext, footer, implicit))
+	if err != nil {
+		return "", err
+	}
+
+	body := append(append(append([]byte{}, nonce...), ciphertext...), mac...)
+
+	return joinPASETO(pasetoLocalHeader, body, footer), nil
+}
+
+func pasetoDecrypt(key []byte, token string) ([]byte, error) {
+	return pasetoOpen(key, token, nil)
+}
+
+// pasetoOpen decrypts the local token with the implicit assertion it is sealed with.
+func pasetoOpen(key []byte, token string, implicit []byte) ([]byte, error) {
+	body, footer, err := splitPASETO(pasetoLocalHeader, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) < pasetoNonceSize+pasetoMACSize {
+		return nil, ErrInvalidToken
+	}
+
+	nonce := body[:pasetoNonceSize]
+	ciphertext := body[pasetoNonceSize : len(body)-pasetoMACSize]
+	mac := body[len(body)-pasetoMACSize:]
+
+	encKey, counterNonce, authKey, err := pasetoLocalKeys(key, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := pasetoMAC(authKey, pae([]byte(pasetoLocalHeader), nonce, ciphertext, footer, implicit))
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(mac, expected) {
+		return nil, ErrInvalidToken
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(encKey, counterNonce)
+	if err != nil {
+		return nil, err
+	}
+
+	message := make([]byte, len(ciphertext))
+	cipher.XORKeyStream(message, ciphertext)
+
+	return message, nil
+}
+
+// pasetoSign signs the message, the footer and the implicit assertion which is not appended to the token.
+func pasetoSign(key ed25519.PrivateKey, message []byte, footer []byte, implicit []byte) string {
+	signature := ed25519.Sign(key, pae([]byte(pasetoPublicHeader), message, footer, implicit))
+
+	body := append(append([]byte{}, message...), signature...)
+
+	return joinPASETO(pasetoPublicHeader, body, footer)
+}
+
+func pasetoVerify(key ed25519.PublicKey, token string, implicit []byte) ([]byte, error) {
+	body, footer, err := splitPASETO(pasetoPublicHeader, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+
+	if !ed25519.Verify(key, pae([]byte(pasetoPublicHeader), message, footer, implicit), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	return message, nil
+}
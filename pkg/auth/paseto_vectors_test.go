@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The test vectors are the v4 vectors of https://github.com/paseto-standard/test-vectors
+// which check the tokens against the reference implementations.
+
+const (
+	vectorNonce    = "df654812bac492663825520ba2f6e67cf5ca5bdc13d4e7507a98cc4c2fcc3ad8"
+	vectorFooter   = `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`
+	vectorSecret   = `{"data":"this is a secret message","exp":"2022-01-01T00:00:00+00:00"}`
+	vectorHidden   = `{"data":"this is a hidden message","exp":"2022-01-01T00:00:00+00:00"}`
+	vectorSigned   = `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+	vectorLocalKey = "707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f"
+	vectorSignKey  = "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestPASETOLocalVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		nonce    string
+		payload  string
+		footer   string
+		implicit string
+		token    string
+	}{
+		{
+			name:    "4-E-1",
+			nonce:   "0000000000000000000000000000000000000000000000000000000000000000",
+			payload: vectorSecret,
+			token:   "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvSwscFlAl1pk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XJ5hOb_4v9RmDkneN0S92dx0OW4pgy7omxgf3S8c3LlQg",
+		},
+		{
+			name:    "4-E-2",
+			nonce:   "0000000000000000000000000000000000000000000000000000000000000000",
+			payload: vectorHidden,
+			token:   "v4.local.AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAQAr68PS4AXe7If_ZgesdkUMvS2csCgglvpk5HC0e8kApeaqMfGo_7OpBnwJOAbY9V7WU6abu74MmcUE8YWAiaArVI8XIemu9chy3WVKvRBfg6t8wwYHK0ArLxxfZP73W_vfwt5A",
+		},
+		{
+			name:    "4-E-3",
+			nonce:   vectorNonce,
+			payload: vectorSecret,
+			token:   "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6-tyebyWG6Ov7kKvBdkrrAJ837lKP3iDag2hzUPHuMKA",
+		},
+		{
+			name:    "4-E-4",
+			nonce:   vectorNonce,
+			payload: vectorHidden,
+			token:   "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4gt6TiLm55vIH8c_lGxxZpE3AWlH4WTR0v45nsWoU3gQ",
+		},
+		{
+			name:    "4-E-5",
+			nonce:   vectorNonce,
+			payload: vectorSecret,
+			footer:  vectorFooter,
+			token:   "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t4x-RMNXtQNbz7FvFZ_G-lFpk5RG3EOrwDL6CgDqcerSQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+		{
+			name:    "4-E-6",
+			nonce:   vectorNonce,
+			payload: vectorHidden,
+			footer:  vectorFooter,
+			token:   "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WiA8rd3wgFSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t6pWSA5HX2wjb3P-xLQg5K5feUCX4P2fpVK3ZLWFbMSxQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+		{
+			name:     "4-E-7",
+			nonce:    vectorNonce,
+			payload:  vectorSecret,
+			footer:   vectorFooter,
+			implicit: `{"test-vector":"4-E-7"}`,
+			token:    "v4.local.32VIErrEkmY4JVILovbmfPXKW9wT1OdQepjMTC_MOtjA4kiqw7_tcaOM5GNEcnTxl60WkwMsYXw6FSNb_UdJPXjpzm0KW9ojM5f4O2mRvE2IcweP-PRdoHjd5-RHCiExR1IK6t40KCCWLA7GYL9KFHzKlwY9_RnIfRrMQpueydLEAZGGcA.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+	}
+
+	key := decodeHex(t, vectorLocalKey)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := pasetoSeal(key, decodeHex(t, tt.nonce), []byte(tt.payload), []byte(tt.footer), []byte(tt.implicit))
+			require.NoError(t, err)
+			require.Equal(t, tt.token, token)
+
+			payload, err := pasetoOpen(key, tt.token, []byte(tt.implicit))
+			require.NoError(t, err)
+			require.Equal(t, tt.payload, string(payload))
+
+			_, err = pasetoOpen(key, tt.token, []byte("wrong implicit assertion"))
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestPASETOPublicVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		footer   string
+		implicit string
+		token    string
+	}{
+		{
+			name:  "4-S-1",
+			token: "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA",
+		},
+		{
+			name:   "4-S-2",
+			footer: vectorFooter,
+			token:  "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9v3Jt8mx_TdM2ceTGoqwrh4yDFn0XsHvvV_D0DtwQxVrJEBMl0F2caAdgnpKlt4p7xBnx1HcO-SPo8FPp214HDw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+		{
+			name:     "4-S-3",
+			footer:   vectorFooter,
+			implicit: `{"test-vector":"4-S-3"}`,
+			token:    "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9NPWciuD3d0o5eXJXG5pJy-DiVEoyPYWs1YSTwWHNJq6DZD3je5gf-0M4JR9ipdUSJbIovzmBECeaWmaqcaP0DQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+	}
+
+	privateKey := ed25519.PrivateKey(decodeHex(t, vectorSignKey))
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	require.Equal(t, "1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2", hex.EncodeToString(publicKey))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The Ed25519 signatures are deterministic, so the tokens must be the same.
+			token := pasetoSign(privateKey, []byte(vectorSigned), []byte(tt.footer), []byte(tt.implicit))
+			require.Equal(t, tt.token, token)
+
+			payload, err := pasetoVerify(publicKey, tt.token, []byte(tt.implicit))
+			require.NoError(t, err)
+			require.Equal(t, vectorSigned, string(payload))
+
+			_, err = pasetoVerify(publicKey, tt.token, []byte("wrong implicit assertion"))
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PasswordPolicyError is the reason a password is rejected by the policy.
+// Its message can be shown to the user.
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Reason
+}
+
+// bcryptMaxLength is the number of bytes bcrypt uses from a password.
+const bcryptMaxLength = 72
+
+// PasswordPolicy is the rules the new passwords must follow. The zero values
+// of the fields disable their rules except for the maximum length.
+type PasswordPolicy struct {
+	MinLength int
+	// MinClasses is the number of the character classes of lowercase
+	// letters, uppercase letters, digits and symbols the password must have.
+	MinClasses int
+	// MinScore is the minimum strength score of PasswordStrength from 0 to 4.
+	MinScore int
+	// DisallowPersonal rejects the passwords containing the name or the email of the user.
+	DisallowPersonal bool
+	// Breached rejects the passwords in the breached password list if it is set.
+	Breached *BreachedPasswords
+}
+
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:        8,
+		MinScore:         2,
+		DisallowPersonal: true,
+	}
+}
+
+// Validate returns a *PasswordPolicyError if the password does not follow the policy. The personal
+// values are the name and the email of the user. The other errors are from the breached password list.
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	length := utf8.RuneCountInString(password)
+
+	if length < p.MinLength {
+		return &PasswordPolicyError{fmt.Sprintf("must be at least %d characters long", p.MinLength)}
+	}
+
+	if len(password) > bcryptMaxLength {
+		return &PasswordPolicyError{fmt.Sprintf("must not be more than %d bytes long", bcryptMaxLength)}
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		return &PasswordPolicyError{fmt.Sprintf("must contain at least %d of lowercase letters, uppercase letters, digits and symbols", p.MinClasses)}
+	}
+
+	if p.DisallowPersonal && containsPersonal(password, personal) {
+		return &PasswordPolicyError{"must not contain your name or email"}
+	}
+
+	if PasswordStrength(password, personal...) < p.MinScore {
+		return &PasswordPolicyError{"is too easy to guess"}
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+
+		if breached {
+			return &PasswordPolicyError{"has appeared in a data breach, please choose another password"}
+		}
+	}
+
+	return nil
+}
+
+// IsPolicyError reports whether the error is a rejection of the password policy.
+func IsPolicyError(err error) bool {
+	var policyErr *PasswordPolicyError
+	return errors.As(err, &policyErr)
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// personalParts returns the parts of the name and the email which
+// are long enough to be meaningful in a password.
+func personalParts(personal []string) []string {
+	var parts []string
+
+	for _, value := range personal {
+		value = strings.ToLower(value)
+
+		if at := strings.LastIndex(value, "@"); at >= 0 {
+			value = value[:at]
+		}
+
+		fields := strings.FieldsFunc(value, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		for _, field := range fields {
+			if utf8.RuneCountInString(field) >= 3 {
+				parts = append(parts, field)
+			}
+		}
+	}
+
+	return parts
+}
+
+func containsPersonal(password string, personal []string) bool {
+	lower := strings.ToLower(password)
+
+	for _, part := range personalParts(personal) {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PasswordStrength estimates the strength of the password from 0 to 4 like zxcvbn does. The
+// guesses are estimated from the character set and the length of the password where the
+// common passwords, the keyboard and alphabetical sequences, the repeated characters and
+// the personal values count as a few guesses.
+func PasswordStrength(password string, personal ...string) int {
+	lower := strings.ToLower(password)
+	// The leet replacements keep the byte positions since they are all ASCII.
+	normalized := unleet(lower)
+
+	if _, ok := commonPasswords[normalized]; ok {
+		return 0
+	}
+
+	words := personalParts(personal)
+	for word := range commonPasswords {
+		if len(word) >= 5 {
+			words = append(words, word)
+		}
+	}
+
+	// matched marks the bytes of the personal values and the common passwords.
+	// The first byte of a match is 1 and the rest of it is 2.
+	matched := make([]byte, len(normalized))
+	for _, word := range words {
+		for start := 0; start < len(normalized); {
+			i := strings.Index(normalized[start:], word)
+			if i < 0 {
+				break
+			}
+
+			i += start
+			if matched[i] == 0 {
+				matched[i] = 1
+			}
+			for j := i + 1; j < i+len(word); j++ {
+				matched[j] = 2
+			}
+
+			start = i + len(word)
+		}
+	}
+
+	// Every character which continues a repeat or a sequence counts as a quarter
+	// of a character and every matched word counts as a single character.
+	effective := 0.0
+	prev := rune(-1)
+	for i, r := range lower {
+		switch {
+		case matched[i] == 2:
+		case matched[i] == 1:
+			effective += 1
+		case prev >= 0 && (r == prev || isSequence(prev, r)):
+			effective += 0.25
+		default:
+			effective += 1
+		}
+
+		prev = r
+	}
+
+	guesses := math.Pow(float64(charsetSize(password)), effective)
+	log10 := math.Log10(guesses)
+
+	switch {
+	case log10 < 4:
+		return 0
+	case log10 < 7:
+		return 1
+	case log10 < 9:
+		return 2
+	case log10 < 11:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func charsetSize(password string) int {
+	var size int
+	var lower, upper, digit, symbol, other bool
+
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if symbol {
+		size += 33
+	}
+	if other {
+		size += 100
+	}
+
+	return size
+}
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"abcdefghijklmnopqrstuvwxyz",
+}
+
+// isSequence reports whether b follows a in the alphabet, the digits or a
+// keyboard row in either direction.
+func isSequence(a rune, b rune) bool {
+	for _, row := range keyboardRows {
+		i := strings.IndexRune(row, a)
+		j := strings.IndexRune(row, b)
+
+		if i >= 0 && j >= 0 && (j-i == 1 || i-j == 1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var leetReplacer = strings.NewReplacer("4", "a", "@", "a", "3", "e", "1", "i", "!", "i", "0", "o", "$", "s", "5", "s", "7", "t")
+
+func unleet(s string) string {
+	return leetReplacer.Replace(s)
+}
+
+var commonPasswords = map[string]struct{}{}
+
+func init() {
+	for _, password := range strings.Fields(commonPasswordList) {
+		commonPasswords[unleet(password)] = struct{}{}
+	}
+}
+
+// commonPasswordList is the most common passwords of the public breach corpora.
+const commonPasswordList = `
+password passw0rd password1 password12 password123 123456 1234567 12345678 123456789 1234567890
+qwerty qwertyuiop qwerty123 abc123 111111 123123 000000 iloveyou admin admin123 welcome welcome1
+letmein monkey dragon football baseball basketball soccer hockey master sunshine princess shadow
+superman batman trustno1 freedom whatever michael jennifer jordan hunter ranger buster thomas
+charlie daniel andrew joshua matthew ashley jessica amanda summer winter spring autumn secret
+login starwars pokemon computer internet samsung google mustang access flower cheese hello
+hello123 loveme lovely qazwsx zaq12wsx asdfgh asdfghjkl zxcvbnm 654321 666666 696969 987654321
+changeme default guest root test test123 pass pass123 mypassword p@ssword passwort motdepasse
+contrasena senha parola sifre banana orange apple chocolate cookie pepper maggie ginger tigger
+killer hannah nicole money family friends forever blessed jesus angel naruto
+`
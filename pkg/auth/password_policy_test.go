@@ -0,0 +1,109 @@
+package auth_test
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := auth.DefaultPasswordPolicy()
+	policy.MinClasses = 2
+
+	tests := []struct {
+		name     string
+		password string
+		personal []string
+		valid    bool
+	}{
+		{"strong password", "vivid-Otter-harbor-42", nil, true},
+		{"too short", "Ab1!x", nil, false},
+		{"too long", strings.Repeat("Ab1!", 19), nil, false},
+		{"single character class", "correcthorsebatterystaple", nil, false},
+		{"contains the name", "Ahmet-Zeta-2041", []string{"Ahmet Yilmaz"}, false},
+		{"contains the email", "zx9-mehmet.qp1", []string{"Ali", "mehmet.kaya@example.com"}, false},
+		{"short name is ignored", "Al-vivid-Otter-42", []string{"Al"}, true},
+		{"common password", "Password123", nil, false},
+		{"leet common password", "P@ssw0rd", nil, false},
+		{"keyboard sequence", "Qwertyuiop1", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, tt.personal...)
+			if tt.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.True(t, auth.IsPolicyError(err))
+			}
+		})
+	}
+}
+
+func TestPasswordStrength(t *testing.T) {
+	require.Equal(t, 0, auth.PasswordStrength("password"))
+	require.Equal(t, 0, auth.PasswordStrength("abcdefgh"))
+	require.Less(t, auth.PasswordStrength("aaaaaaaaaaaa"), 2)
+	require.Less(t, auth.PasswordStrength("sunshine99"), 2)
+	require.Equal(t, 0, auth.PasswordStrength("Nebisin!", "nebisin@example.com"))
+	require.Equal(t, 4, auth.PasswordStrength("vivid-Otter-harbor-42"))
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	dir := t.TempDir()
+
+	writeRange := func(password string, count string) {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+		content := "0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n" + strings.ToLower(hash[5:]) + ":" + count + "\r\n"
+		err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0o600)
+		require.NoError(t, err)
+	}
+
+	writeRange("vivid-Otter-harbor-42", "3")
+	writeRange("calm-Badger-river-17", "0")
+
+	breached, err := auth.NewBreachedPasswords(dir)
+	require.NoError(t, err)
+
+	t.Run("breached password", func(t *testing.T) {
+		ok, err := breached.Contains("vivid-Otter-harbor-42")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("padding entry", func(t *testing.T) {
+		ok, err := breached.Contains("calm-Badger-river-17")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("missing range file", func(t *testing.T) {
+		ok, err := breached.Contains("quiet-Falcon-meadow-93")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("policy rejects breached password", func(t *testing.T) {
+		policy := auth.DefaultPasswordPolicy()
+		policy.Breached = breached
+
+		err := policy.Validate("vivid-Otter-harbor-42")
+		require.True(t, auth.IsPolicyError(err))
+
+		require.NoError(t, policy.Validate("quiet-Falcon-meadow-93"))
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := auth.NewBreachedPasswords(filepath.Join(dir, "missing"))
+		require.Error(t, err)
+	})
+}
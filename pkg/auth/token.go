@@ -2,7 +2,6 @@ package auth
 
 import (
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -10,37 +9,78 @@ import (
 
 const minSecretSize = 32
 
+// symmetricKeyID is the ID of the key of the makers created with a secret.
+const symmetricKeyID = "default"
+
 type JWTMaker struct {
-	secretKey string
+	keys     *KeySet
+	issuer   string
+	audience string
 }
 
+// NewJWTMaker returns a maker which signs the tokens with HS256 using the secret.
 func NewJWTMaker(secretKey string) (*JWTMaker, error) {
-	if len(secretKey) < minSecretSize {
-		return nil, fmt.Errorf("key size must be at least %d characters", minSecretSize)
+	key, err := NewSymmetricKey(symmetricKeyID, secretKey)
+	if err != nil {
+		return nil, err
 	}
 
-	return &JWTMaker{secretKey: secretKey}, nil
+	keys, err := NewKeySet(symmetricKeyID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTMaker{keys: keys}, nil
+}
+
+// NewJWTMakerWithKeys returns a maker which signs the tokens with the signing key of the set
+// and verifies them with any key of the set. The issuer and the audience are set on the tokens
+// and required from the verified ones unless they are empty.
+func NewJWTMakerWithKeys(keys *KeySet, issuer string, audience string) *JWTMaker {
+	return &JWTMaker{keys: keys, issuer: issuer, audience: audience}
 }
 
-func (maker *JWTMaker) CreateToken(userID int64, duration time.Duration) (string, error) {
-	payload, err := NewPayload(userID, duration)
+func (maker *JWTMaker) CreateToken(userID int64, sessionID int64, duration time.Duration) (string, error) {
+	payload, err := NewPayload(userID, sessionID, duration)
 	if err != nil {
 		return "", err
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	return jwtToken.SignedString([]byte(maker.secretKey))
+	payload.Issuer = maker.issuer
+	if maker.audience != "" {
+		payload.Audience = []string{maker.audience}
+	}
+
+	key := maker.keys.signing
+
+	jwtToken := jwt.NewWithClaims(key.Method, payload)
+	jwtToken.Header["kid"] = key.ID
+
+	return jwtToken.SignedString(key.private)
 }
 
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
-		_, ok := token.Method.(*jwt.SigningMethodHMAC)
-		if !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			// The tokens which are signed before the key IDs have no ID.
+			kid = symmetricKeyID
+		}
+
+		key, err := maker.keys.Get(kid)
+		if err != nil {
 			return nil, ErrInvalidToken
 		}
 
-		return []byte(maker.secretKey), nil
+		// The algorithm of the token must be the algorithm of the key, otherwise
+		// a public key could be used as the secret of an HS256 token.
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+
+		return key.public, nil
 	}
+
 	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
 	if err != nil {
 		verr, ok := err.(*jwt.ValidationError)
@@ -55,5 +95,18 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrInvalidToken
 	}
 
+	if maker.issuer != "" && payload.Issuer != maker.issuer {
+		return nil, ErrInvalidToken
+	}
+
+	if maker.audience != "" && !payload.HasAudience(maker.audience) {
+		return nil, ErrInvalidToken
+	}
+
 	return payload, nil
 }
+
+// JWKS returns the public keys the tokens can be verified with.
+func (maker *JWTMaker) JWKS() JWKS {
+	return maker.keys.JWKS()
+}
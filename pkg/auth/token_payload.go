@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 )
 
@@ -10,22 +14,79 @@ var (
 	ErrInvalidToken = errors.New("token is invalid")
 )
 
+// Payload is the claims of the access tokens. The registered claims of
+// RFC 7519 are used so that the other services can verify the tokens.
 type Payload struct {
+	ID        string    `json:"jti"`
+	Issuer    string    `json:"iss,omitempty"`
+	Subject   string    `json:"sub"`
+	Audience  []string  `json:"aud,omitempty"`
 	UserID    int64     `json:"user_id"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expired_at"`
+	SessionID int64     `json:"session_id,omitempty"`
+	IssuedAt  time.Time `json:"-"`
+	ExpiredAt time.Time `json:"-"`
 }
 
-func NewPayload(userID int64, duration time.Duration) (*Payload, error) {
+func NewPayload(userID int64, sessionID int64, duration time.Duration) (*Payload, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
 	payload := &Payload{
+		ID:        hex.EncodeToString(id),
+		Subject:   strconv.FormatInt(userID, 10),
 		UserID:    userID,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		SessionID: sessionID,
+		IssuedAt:  now,
+		ExpiredAt: now.Add(duration),
 	}
 
 	return payload, nil
 }
 
+type payloadAlias Payload
+
+// payloadJSON encodes the times as the numeric dates of RFC 7519.
+type payloadJSON struct {
+	*payloadAlias
+	IssuedAt  int64 `json:"iat"`
+	ExpiredAt int64 `json:"exp"`
+}
+
+func (p Payload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(payloadJSON{
+		payloadAlias: (*payloadAlias)(&p),
+		IssuedAt:     p.IssuedAt.Unix(),
+		ExpiredAt:    p.ExpiredAt.Unix(),
+	})
+}
+
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	aux := payloadJSON{payloadAlias: (*payloadAlias)(p)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	p.IssuedAt = time.Unix(aux.IssuedAt, 0)
+	p.ExpiredAt = time.Unix(aux.ExpiredAt, 0)
+
+	return nil
+}
+
+// HasAudience reports whether the token is issued for the audience.
+func (p *Payload) HasAudience(audience string) bool {
+	for _, aud := range p.Audience {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Payload) Valid() error {
 	if time.Now().After(p.ExpiredAt) {
 		return ErrExpiredToken
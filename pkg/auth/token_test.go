@@ -22,8 +22,9 @@ func TestJWTMaker_VerifyToken(t *testing.T) {
 
 	t.Run("success case for verify token", func(t *testing.T) {
 		userID := random.Int(2, 6)
+		sessionID := random.Int(1, 1000)
 
-		token, err := maker.CreateToken(userID, time.Hour)
+		token, err := maker.CreateToken(userID, sessionID, time.Hour)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 
@@ -32,12 +33,13 @@ func TestJWTMaker_VerifyToken(t *testing.T) {
 		require.NotEmpty(t, payload)
 
 		require.Equal(t, payload.UserID, userID)
+		require.Equal(t, payload.SessionID, sessionID)
 	})
 
 	t.Run("expired token case for verify token", func(t *testing.T) {
 		userID := random.Int(2, 6)
 
-		token, err := maker.CreateToken(userID, time.Nanosecond-2)
+		token, err := maker.CreateToken(userID, 0, time.Nanosecond-2)
 		require.NoError(t, err)
 		require.NotEmpty(t, token)
 
@@ -0,0 +1,88 @@
+package auth_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/nebisin/goExpense/pkg/auth"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors of RFC 6238.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// The codes are the last six digits of the eight digit codes in RFC 6238.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := auth.TOTPCode(rfcSecret, auth.TOTPStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tt.code, code)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := auth.NewTOTPSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	now := time.Now()
+
+	t.Run("success case for validate totp", func(t *testing.T) {
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(now))
+		require.NoError(t, err)
+
+		step, ok, err := auth.ValidateTOTP(secret, code, now)
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, auth.TOTPStep(now), step)
+	})
+
+	t.Run("clock drift case for validate totp", func(t *testing.T) {
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(now)-1)
+		require.NoError(t, err)
+
+		_, ok, err := auth.ValidateTOTP(secret, code, now)
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("expired code case for validate totp", func(t *testing.T) {
+		code, err := auth.TOTPCode(secret, auth.TOTPStep(now)-2)
+		require.NoError(t, err)
+
+		_, ok, err := auth.ValidateTOTP(secret, code, now)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("malformed code case for validate totp", func(t *testing.T) {
+		_, ok, err := auth.ValidateTOTP(secret, "12345", now)
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(auth.TOTPURI("goExpense", "user@example.com", "SECRET"))
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/goExpense:user@example.com", uri.Path)
+	require.Equal(t, "SECRET", uri.Query().Get("secret"))
+	require.Equal(t, "goExpense", uri.Query().Get("issuer"))
+}
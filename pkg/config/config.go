@@ -1,15 +1,39 @@
 package config
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/nebisin/goExpense/pkg/oidc"
 	"github.com/spf13/viper"
 )
 
+// RateLimitPolicy allows Rate requests in every Period with bursts of up to Burst requests
+// on its routes. The routes are the path templates with an optional method such as
+// "POST /api/v1/users". The policy named default is used for the other routes.
+type RateLimitPolicy struct {
+	Name   string   `json:"name"`
+	Rate   int64    `json:"rate"`
+	Period string   `json:"period"`
+	Burst  int64    `json:"burst"`
+	Routes []string `json:"routes"`
+}
+
 type Config struct {
-	Port      int    `mapstructure:"PORT"`
-	Env       string `mapstructure:"ENV"`
-	DbURI     string `mapstructure:"DB_URI"`
-	JwtSecret string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	SMTP      struct {
+	Port                 int           `mapstructure:"PORT"`
+	Env                  string        `mapstructure:"ENV"`
+	DbURI                string        `mapstructure:"DB_URI"`
+	JwtSecret            string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenType            string        `mapstructure:"TOKEN_TYPE"`
+	TokenKeysPath        string        `mapstructure:"TOKEN_KEYS_PATH"`
+	TokenSigningKeyID    string        `mapstructure:"TOKEN_SIGNING_KEY_ID"`
+	TokenIssuer          string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience        string        `mapstructure:"TOKEN_AUDIENCE"`
+	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	AnomalyAlerts        bool          `mapstructure:"ANOMALY_EMAIL_ALERTS"`
+	TrashRetention       time.Duration `mapstructure:"TRASH_RETENTION"`
+	SMTP                 struct {
 		Host     string `mapstructure:"SMTP_HOST"`
 		Port     int    `mapstructure:"SMTP_PORT"`
 		Username string `mapstructure:"SMTP_USERNAME"`
@@ -24,6 +48,37 @@ type Config struct {
 	CORS struct {
 		TrustedOrigins []string `mapstructure:"CORS_TRUSTED_ORIGINS"`
 	}
+	// Proxies is the reverse proxies whose forwarding header gives the address of the
+	// client. The header is X-Forwarded-For or Forwarded and the proxies are CIDRs.
+	Proxies struct {
+		Trusted []string `mapstructure:"TRUSTED_PROXIES"`
+		Header  string   `mapstructure:"TRUSTED_PROXY_HEADER"`
+	}
+	// ExchangeRates is where the rates of the currencies against USD come from. The rates
+	// are stored at the start from EXCHANGE_RATES such as "EUR=0.92,TRY=32.5" and updated
+	// in every interval from EXCHANGE_RATES_URL which returns the latest rates like
+	// https://open.er-api.com/v6/latest/USD does. The reports can only convert the
+	// currencies with a rate.
+	ExchangeRates struct {
+		Rates    string        `mapstructure:"EXCHANGE_RATES"`
+		URL      string        `mapstructure:"EXCHANGE_RATES_URL"`
+		Interval time.Duration `mapstructure:"EXCHANGE_RATES_INTERVAL"`
+	}
+	// PasswordPolicy is the rules of the new passwords. The zero values of the length, the
+	// classes and the score and an unset personal rule use the defaults. The breached passwords
+	// path is a directory of the SHA-1 hash range files in the format of the Have I Been Pwned range API.
+	PasswordPolicy struct {
+		MinLength        int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+		MinClasses       int    `mapstructure:"PASSWORD_MIN_CLASSES"`
+		MinScore         int    `mapstructure:"PASSWORD_MIN_SCORE"`
+		DisallowPersonal *bool  `mapstructure:"PASSWORD_DISALLOW_PERSONAL"`
+		BreachedPath     string `mapstructure:"PASSWORD_BREACHED_PATH"`
+	}
+	// RateLimitPolicies is read from RATE_LIMIT_POLICIES which is a JSON array of the
+	// policies. They replace the built-in policies with the same names.
+	RateLimitPolicies []RateLimitPolicy `mapstructure:"-"`
+	// OIDCProviders is read from OIDC_PROVIDERS which is a JSON array of the providers.
+	OIDCProviders []oidc.Config `mapstructure:"-"`
 }
 
 func LoadConfig(path string, name string) (cfg Config, err error) {
@@ -41,6 +96,17 @@ func LoadConfig(path string, name string) (cfg Config, err error) {
 	err = viper.Unmarshal(&cfg)
 	err = viper.Unmarshal(&cfg.SMTP)
 	err = viper.Unmarshal(&cfg.CORS)
+	err = viper.Unmarshal(&cfg.Proxies)
 	err = viper.Unmarshal(&cfg.RedisConfig)
+	err = viper.Unmarshal(&cfg.PasswordPolicy)
+	err = viper.Unmarshal(&cfg.ExchangeRates)
+
+	if providers := viper.GetString("OIDC_PROVIDERS"); providers != "" {
+		err = json.Unmarshal([]byte(providers), &cfg.OIDCProviders)
+	}
+
+	if policies := viper.GetString("RATE_LIMIT_POLICIES"); policies != "" && err == nil {
+		err = json.Unmarshal([]byte(policies), &cfg.RateLimitPolicies)
+	}
 	return
 }
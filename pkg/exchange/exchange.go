@@ -0,0 +1,118 @@
+// Package exchange reads the exchange rates from the configuration and
+// from the exchange rate APIs which return the latest rates as JSON.
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRates is returned when the rates cannot be parsed.
+var ErrInvalidRates = errors.New("invalid exchange rates")
+
+// ParseRates parses the rates in the "EUR=0.92,TRY=32.5" format where
+// every rate is the amount of the currency which equals one unit of the base.
+func ParseRates(s string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%w: %q must be in the CURRENCY=RATE format", ErrInvalidRates, pair)
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(parts[0]))
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || rate <= 0 || len(currency) != 3 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRates, pair)
+		}
+
+		rates[currency] = rate
+	}
+
+	return rates, nil
+}
+
+// latestResponse is the response of the APIs such as open.er-api.com and
+// exchangerate.host which name the base currency either base or base_code.
+type latestResponse struct {
+	Base     string             `json:"base"`
+	BaseCode string             `json:"base_code"`
+	Rates    map[string]float64 `json:"rates"`
+}
+
+// Fetch gets the latest rates from the URL and returns them against the base currency.
+func Fetch(ctx context.Context, client *http.Client, url string, base string) (map[string]float64, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("exchange rates request failed with status %d", res.StatusCode)
+	}
+
+	var latest latestResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1_048_576)).Decode(&latest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+	}
+
+	from := latest.Base
+	if from == "" {
+		from = latest.BaseCode
+	}
+
+	return Rebase(latest.Rates, from, base)
+}
+
+// Rebase converts the rates against the from currency to the rates against the base currency.
+func Rebase(rates map[string]float64, from string, base string) (map[string]float64, error) {
+	from = strings.ToUpper(from)
+	base = strings.ToUpper(base)
+
+	baseRate := 1.0
+	if from != base {
+		rate, ok := rates[base]
+		if !ok || rate <= 0 {
+			return nil, fmt.Errorf("%w: missing the rate of %s", ErrInvalidRates, base)
+		}
+		baseRate = rate
+	}
+
+	rebased := make(map[string]float64, len(rates)+1)
+	for currency, rate := range rates {
+		if rate <= 0 {
+			continue
+		}
+		rebased[strings.ToUpper(currency)] = rate / baseRate
+	}
+
+	if from != "" {
+		rebased[from] = 1 / baseRate
+	}
+	rebased[base] = 1
+
+	return rebased, nil
+}
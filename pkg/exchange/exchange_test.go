@@ -0,0 +1,65 @@
+package exchange_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nebisin/goExpense/pkg/exchange"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRates(t *testing.T) {
+	rates, err := exchange.ParseRates(" eur=0.5, TRY=10 ,")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"EUR": 0.5, "TRY": 10}, rates)
+
+	rates, err = exchange.ParseRates("")
+	require.NoError(t, err)
+	require.Empty(t, rates)
+
+	for _, invalid := range []string{"EUR", "EUR=abc", "EUR=0", "EU=1", "EUR=-2"} {
+		_, err := exchange.ParseRates(invalid)
+		require.ErrorIs(t, err, exchange.ErrInvalidRates, invalid)
+	}
+}
+
+func TestRebase(t *testing.T) {
+	rates, err := exchange.Rebase(map[string]float64{"USD": 2, "TRY": 20}, "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"USD": 1, "EUR": 0.5, "TRY": 10}, rates)
+
+	_, err = exchange.Rebase(map[string]float64{"TRY": 20}, "EUR", "USD")
+	require.ErrorIs(t, err, exchange.ErrInvalidRates)
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/base":
+			w.Write([]byte(`{"base":"USD","rates":{"EUR":0.5,"TRY":10}}`))
+		case "/base-code":
+			w.Write([]byte(`{"result":"success","base_code":"EUR","rates":{"EUR":1,"USD":2}}`))
+		case "/invalid":
+			w.Write([]byte(`<html>`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	rates, err := exchange.Fetch(context.Background(), srv.Client(), srv.URL+"/base", "USD")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"USD": 1, "EUR": 0.5, "TRY": 10}, rates)
+
+	rates, err = exchange.Fetch(context.Background(), srv.Client(), srv.URL+"/base-code", "USD")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"USD": 1, "EUR": 0.5}, rates)
+
+	_, err = exchange.Fetch(context.Background(), srv.Client(), srv.URL+"/invalid", "USD")
+	require.ErrorIs(t, err, exchange.ErrInvalidRates)
+
+	_, err = exchange.Fetch(context.Background(), srv.Client(), srv.URL+"/error", "USD")
+	require.Error(t, err)
+}
@@ -0,0 +1,313 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nebisin/goExpense/pkg/oidc"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProvider is a local OpenID Connect provider which issues
+// the ID token given to it for the codes it hands out.
+type stubProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu         sync.Mutex
+	challenges map[string]string
+	claims     jwt.MapClaims
+	// malformed makes the token endpoint respond with a body which is not JSON.
+	malformed bool
+}
+
+func newStubProvider(t *testing.T) *stubProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &stubProvider{t: t, key: key, kid: "k1", challenges: map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": p.kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		p.mu.Lock()
+		challenge, ok := p.challenges[r.PostForm.Get("code")]
+		delete(p.challenges, r.PostForm.Get("code"))
+		p.mu.Unlock()
+
+		if !ok || oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		if p.malformed {
+			w.Write([]byte("<html>"))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "access", "id_token": p.sign(p.claims)})
+	})
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// authorize plays the user consenting on the provider and returns the code.
+func (p *stubProvider) authorize(authURL string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(p.t, err)
+
+	q := u.Query()
+	require.Equal(p.t, "S256", q.Get("code_challenge_method"))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	code := "code-" + q.Get("state")
+	p.challenges[code] = q.Get("code_challenge")
+
+	return code
+}
+
+func (p *stubProvider) sign(claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+
+	signed, err := token.SignedString(p.key)
+	require.NoError(p.t, err)
+
+	return signed
+}
+
+func (p *stubProvider) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            p.server.URL,
+		"sub":            "subject-1",
+		"aud":            "client",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "user@example.com",
+		"email_verified": true,
+		"name":           "User",
+	}
+}
+
+func (p *stubProvider) config() oidc.Config {
+	return oidc.Config{
+		Name:         "stub",
+		Issuer:       p.server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+	}
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := oidc.NewProvider(stub.config(), nil)
+	ctx := context.Background()
+
+	t.Run("success case for exchange", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+		require.NoError(t, err)
+
+		u, err := url.Parse(authURL)
+		require.NoError(t, err)
+		require.Equal(t, "client", u.Query().Get("client_id"))
+		require.Equal(t, "openid email profile", u.Query().Get("scope"))
+		require.Equal(t, "nonce-1", u.Query().Get("nonce"))
+
+		stub.claims = stub.validClaims("nonce-1")
+		code := stub.authorize(authURL)
+
+		claims, err := provider.Exchange(ctx, code, verifier, "nonce-1")
+		require.NoError(t, err)
+		require.Equal(t, "subject-1", claims.Subject)
+		require.Equal(t, "user@example.com", claims.Email)
+		require.True(t, claims.EmailVerified)
+		require.Equal(t, "User", claims.Name)
+	})
+
+	t.Run("wrong verifier case for exchange", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-2", "nonce-2", verifier)
+		require.NoError(t, err)
+
+		stub.claims = stub.validClaims("nonce-2")
+		code := stub.authorize(authURL)
+
+		other, err := oidc.NewVerifier()
+		require.NoError(t, err)
+
+		_, err = provider.Exchange(ctx, code, other, "nonce-2")
+		require.ErrorIs(t, err, oidc.ErrExchange)
+	})
+
+	t.Run("wrong nonce case for exchange", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-3", "nonce-3", verifier)
+		require.NoError(t, err)
+
+		stub.claims = stub.validClaims("other")
+		code := stub.authorize(authURL)
+
+		_, err = provider.Exchange(ctx, code, verifier, "nonce-3")
+		require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("malformed response case for exchange", func(t *testing.T) {
+		verifier, err := oidc.NewVerifier()
+		require.NoError(t, err)
+
+		authURL, err := provider.AuthCodeURL(ctx, "state-4", "nonce-4", verifier)
+		require.NoError(t, err)
+
+		stub.claims = stub.validClaims("nonce-4")
+		code := stub.authorize(authURL)
+
+		stub.malformed = true
+		defer func() { stub.malformed = false }()
+
+		_, err = provider.Exchange(ctx, code, verifier, "nonce-4")
+		require.ErrorIs(t, err, oidc.ErrExchange)
+	})
+}
+
+func TestProvider_VerifyIDToken(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := oidc.NewProvider(stub.config(), nil)
+	ctx := context.Background()
+
+	t.Run("success case for verify id token", func(t *testing.T) {
+		claims := stub.validClaims("nonce")
+		claims["aud"] = []string{"other", "client"}
+
+		got, err := provider.VerifyIDToken(ctx, stub.sign(claims), "nonce")
+		require.NoError(t, err)
+		require.Equal(t, "subject-1", got.Subject)
+	})
+
+	invalidCases := map[string]func(jwt.MapClaims){
+		"expired":  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"audience": func(c jwt.MapClaims) { c["aud"] = "other" },
+		"subject":  func(c jwt.MapClaims) { delete(c, "sub") },
+		"nonce":    func(c jwt.MapClaims) { c["nonce"] = "other" },
+	}
+
+	for name, modify := range invalidCases {
+		modify := modify
+
+		t.Run(name+" case for verify id token", func(t *testing.T) {
+			claims := stub.validClaims("nonce")
+			modify(claims)
+
+			_, err := provider.VerifyIDToken(ctx, stub.sign(claims), "nonce")
+			require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+
+	t.Run("symmetric algorithm case for verify id token", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, stub.validClaims("nonce"))
+		token.Header["kid"] = stub.kid
+
+		signed, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = provider.VerifyIDToken(ctx, signed, "nonce")
+		require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+
+	t.Run("other key case for verify id token", func(t *testing.T) {
+		other := newStubProvider(t)
+		claims := stub.validClaims("nonce")
+
+		_, err := provider.VerifyIDToken(ctx, other.sign(claims), "nonce")
+		require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func TestProvider_KeyRefreshLimit(t *testing.T) {
+	stub := newStubProvider(t)
+	provider := oidc.NewProvider(stub.config(), nil)
+	ctx := context.Background()
+
+	_, err := provider.VerifyIDToken(ctx, stub.sign(stub.validClaims("nonce")), "nonce")
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	stub.mu.Lock()
+	stub.key, stub.kid = key, "k2"
+	stub.mu.Unlock()
+
+	// The keys were just fetched, so the new key is not fetched yet.
+	_, err = provider.VerifyIDToken(ctx, stub.sign(stub.validClaims("nonce")), "nonce")
+	require.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+}
+
+func TestProvider_Discovery(t *testing.T) {
+	stub := newStubProvider(t)
+
+	config := stub.config()
+	config.Issuer = stub.server.URL + "/"
+
+	provider := oidc.NewProvider(config, nil)
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	require.Error(t, err)
+}
@@ -0,0 +1,207 @@
+// Package realip finds the address of the client which made a request through
+// the trusted reverse proxies from the Forwarded and X-Forwarded-For headers.
+package realip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context with the address of the client.
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromRequest returns the address of the client of the request set by the resolver, or
+// the address of the peer if the request did not pass through the resolver.
+func FromRequest(r *http.Request) string {
+	if ip, ok := r.Context().Value(contextKey{}).(string); ok {
+		return ip
+	}
+
+	return peerIP(r)
+}
+
+// Resolver trusts the forwarding headers only when they are set by the trusted proxies,
+// so that the clients cannot spoof their addresses by sending the headers themselves.
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+const (
+	HeaderForwarded     = "Forwarded"
+	HeaderXForwardedFor = "X-Forwarded-For"
+)
+
+// NewResolver returns a resolver which trusts the proxies in the CIDRs. The single
+// addresses without a prefix length are accepted as well. Only the header which the
+// proxies set is read, since a proxy passes the other one of the client through.
+// The header is X-Forwarded-For if it is empty.
+func NewResolver(proxies []string, header string) (*Resolver, error) {
+	switch http.CanonicalHeaderKey(header) {
+	case "", HeaderXForwardedFor:
+		header = HeaderXForwardedFor
+	case HeaderForwarded:
+		header = HeaderForwarded
+	default:
+		return nil, fmt.Errorf("invalid forwarding header %q", header)
+	}
+
+	res := &Resolver{header: header}
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address %q", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			res.trusted = append(res.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy network %q: %w", proxy, err)
+		}
+
+		res.trusted = append(res.trusted, network)
+	}
+
+	return res, nil
+}
+
+func (res *Resolver) isTrusted(ip net.IP) bool {
+	for _, network := range res.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP returns the address of the client. The addresses in the forwarding headers are
+// read from the right, where the closest proxy appends the address of its peer, and the
+// first address which is not a trusted proxy is the client.
+func (res *Resolver) ClientIP(r *http.Request) string {
+	client := peerIP(r)
+
+	ip := net.ParseIP(client)
+	if ip == nil || !res.isTrusted(ip) {
+		return client
+	}
+
+	var hops []string
+	if res.header == HeaderForwarded {
+		hops = forwardedFor(r.Header.Values(HeaderForwarded))
+	} else {
+		hops = xForwardedFor(r.Header.Values(HeaderXForwardedFor))
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			// The hops before an invalid or obfuscated address cannot be trusted,
+			// so the proxy which added it is the last known hop.
+			return client
+		}
+
+		client = ip.String()
+
+		if !res.isTrusted(ip) {
+			return client
+		}
+	}
+
+	return client
+}
+
+// Handler sets the address of the client of the requests for FromRequest.
+func (res *Resolver) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewContext(r.Context(), res.ClientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func peerIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
+
+// xForwardedFor returns the addresses of all the X-Forwarded-For headers in order.
+func xForwardedFor(headers []string) []string {
+	var hops []string
+
+	for _, header := range headers {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, stripPort(strings.TrimSpace(hop)))
+		}
+	}
+
+	return hops
+}
+
+// forwardedFor returns the for parameters of all the Forwarded headers of RFC 7239 in order.
+func forwardedFor(headers []string) []string {
+	var hops []string
+
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, value := pair, ""
+				if eq := strings.Index(pair, "="); eq >= 0 {
+					name, value = pair[:eq], pair[eq+1:]
+				}
+
+				if !strings.EqualFold(strings.TrimSpace(name), "for") {
+					continue
+				}
+
+				value = strings.Trim(strings.TrimSpace(value), `"`)
+				hops = append(hops, stripPort(value))
+			}
+		}
+	}
+
+	return hops
+}
+
+// stripPort removes the port and the brackets of the IPv6 addresses such as "[2001:db8::1]:4711".
+func stripPort(hop string) string {
+	if strings.HasPrefix(hop, "[") {
+		if end := strings.Index(hop, "]"); end > 0 {
+			return hop[1:end]
+		}
+		return hop
+	}
+
+	// An IPv4 address with a port has a single colon.
+	if strings.Count(hop, ":") == 1 {
+		return hop[:strings.Index(hop, ":")]
+	}
+
+	return hop
+}
@@ -0,0 +1,119 @@
+package realip_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nebisin/goExpense/pkg/realip"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(remoteAddr string, header http.Header) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	return r
+}
+
+func TestResolver_XForwardedFor(t *testing.T) {
+	res, err := realip.NewResolver([]string{"10.0.0.0/8", "192.168.1.10", "fd00::/8"}, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{"no proxy", "203.0.113.7:5123", nil, "203.0.113.7"},
+		{"untrusted peer is not spoofed", "203.0.113.7:5123", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:80", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"single trusted address", "192.168.1.10:80", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"chain of proxies", "10.0.0.2:80", []string{"198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"spoofed left addresses", "10.0.0.2:80", []string{"1.1.1.1, 198.51.100.1, 10.0.0.5"}, "198.51.100.1"},
+		{"multiple headers", "10.0.0.2:80", []string{"1.1.1.1", "198.51.100.1"}, "198.51.100.1"},
+		{"all trusted", "10.0.0.2:80", []string{"10.0.0.9, 10.0.0.5"}, "10.0.0.9"},
+		{"invalid address", "10.0.0.2:80", []string{"198.51.100.1, unknown, 10.0.0.5"}, "10.0.0.5"},
+		{"address with port", "10.0.0.2:80", []string{"198.51.100.1:4711"}, "198.51.100.1"},
+		{"ipv6 proxy", "[fd00::1]:80", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"no header from trusted proxy", "10.0.0.2:80", nil, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.forwarded != nil {
+				header["X-Forwarded-For"] = tt.forwarded
+			}
+
+			// The Forwarded header is not read unless it is configured.
+			header.Set("Forwarded", "for=192.0.2.99")
+
+			require.Equal(t, tt.expected, res.ClientIP(newRequest(tt.remoteAddr, header)))
+		})
+	}
+}
+
+func TestResolver_Forwarded(t *testing.T) {
+	res, err := realip.NewResolver([]string{"10.0.0.0/8"}, "forwarded")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		forwarded []string
+		expected  string
+	}{
+		{"single element", []string{"for=198.51.100.1;proto=https"}, "198.51.100.1"},
+		{"quoted ipv6 with port", []string{`for="[2001:db8:cafe::17]:4711"`}, "2001:db8:cafe::17"},
+		{"chain of proxies", []string{"for=1.1.1.1, for=198.51.100.1;by=10.0.0.5, for=10.0.0.5"}, "198.51.100.1"},
+		{"case insensitive name", []string{"For=198.51.100.1"}, "198.51.100.1"},
+		{"obfuscated identifier", []string{"for=198.51.100.1, for=_hidden"}, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Forwarded": tt.forwarded}
+
+			// The X-Forwarded-For header is not read unless it is configured.
+			header.Set("X-Forwarded-For", "192.0.2.99")
+
+			require.Equal(t, tt.expected, res.ClientIP(newRequest("10.0.0.2:80", header)))
+		})
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	_, err := realip.NewResolver([]string{"10.0.0.0/33"}, "")
+	require.Error(t, err)
+
+	_, err = realip.NewResolver([]string{"proxy.local"}, "")
+	require.Error(t, err)
+
+	_, err = realip.NewResolver(nil, "X-Real-IP")
+	require.Error(t, err)
+
+	res, err := realip.NewResolver([]string{" 10.0.0.1 ", ""}, "X-Forwarded-For")
+	require.NoError(t, err)
+	require.Equal(t, "198.51.100.1", res.ClientIP(newRequest("10.0.0.1:80", http.Header{"X-Forwarded-For": {"198.51.100.1"}})))
+	require.Equal(t, "10.0.0.2", res.ClientIP(newRequest("10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.1"}})))
+}
+
+func TestHandler(t *testing.T) {
+	res, err := realip.NewResolver([]string{"10.0.0.0/8"}, "")
+	require.NoError(t, err)
+
+	var ip string
+	handler := res.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip = realip.FromRequest(r)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), newRequest("10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.1"}}))
+	require.Equal(t, "198.51.100.1", ip)
+
+	// The address of the peer is used without the handler.
+	require.Equal(t, "10.0.0.2", realip.FromRequest(newRequest("10.0.0.2:80", http.Header{"X-Forwarded-For": {"198.51.100.1"}})))
+}
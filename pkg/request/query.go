@@ -56,3 +56,18 @@ func ReadTime(qs url.Values, key string, defaultValue time.Time) time.Time {
 
 	return t
 }
+
+func ReadBool(qs url.Values, key string, defaultValue bool) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return defaultValue
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return defaultValue
+	}
+
+	return b
+}
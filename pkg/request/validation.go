@@ -41,8 +41,18 @@ func Validate(input interface{}) map[string]string {
 				errorMap[key] = fmt.Sprintf("length must be minimum %s long", fieldError.Param())
 			case fieldError.Tag() == "email":
 				errorMap[key] = "must be a valid email"
+			case fieldError.Tag() == "iso4217":
+				errorMap[key] = "must be a valid ISO 4217 currency code"
 			case fieldError.Tag() == "required_with":
 				errorMap[key] = fmt.Sprintf("must be provided with %s", fieldError.Param())
+			case fieldError.Tag() == "required_without":
+				errorMap[key] = fmt.Sprintf("must be provided when %s is not", fieldError.Param())
+			case fieldError.Tag() == "len":
+				errorMap[key] = fmt.Sprintf("length must be %s", fieldError.Param())
+			case fieldError.Tag() == "numeric":
+				errorMap[key] = "must contain only digits"
+			case fieldError.Tag() == "required_if" || fieldError.Tag() == "required_unless":
+				errorMap[key] = "must be provided for this action"
 			default:
 				errorMap[key] = fmt.Sprint(fieldError.Error())
 			}
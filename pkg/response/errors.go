@@ -2,8 +2,12 @@ package response
 
 import (
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/nebisin/goExpense/pkg/realip"
 	"github.com/sirupsen/logrus"
 )
 
@@ -17,11 +21,12 @@ func Error(w http.ResponseWriter, status int, message interface{}) {
 }
 
 // ServerErrorResponse function is for sending the 500 internal server error to the client.
-// ServerErrorResponse logs the request method, request url with the error message.
+// ServerErrorResponse logs the request method, request url and client ip with the error message.
 func ServerErrorResponse(w http.ResponseWriter, r *http.Request, log *logrus.Logger, err error) {
 	log.WithFields(map[string]interface{}{
 		"request_method": r.Method,
 		"request_url":    r.URL.String(),
+		"client_ip":      realip.FromRequest(r),
 	}).WithError(err).Error("server error response")
 
 	message := "something went wrong"
@@ -81,7 +86,35 @@ func InactiveAccountResponse(w http.ResponseWriter, r *http.Request) {
 	Error(w, http.StatusForbidden, message)
 }
 
-func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request) {
+// RateLimitExceededResponse tells the client when the rate limit allows the next request.
+func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+
 	message := "rate limit exceeded"
 	Error(w, http.StatusTooManyRequests, message)
 }
+
+// TooManyAttemptsResponse tells the client when the failed attempts allow it to try again.
+func TooManyAttemptsResponse(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+
+	message := "too many failed attempts, please try again later"
+	Error(w, http.StatusTooManyRequests, message)
+}
+
+func IdempotencyKeyInProgressResponse(w http.ResponseWriter, r *http.Request) {
+	message := "a request with the same idempotency key is still in progress, please try again later"
+	Error(w, http.StatusConflict, message)
+}
+
+func IdempotencyKeyMismatchResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the idempotency key was already used for a different request"
+	Error(w, http.StatusUnprocessableEntity, message)
+}
+
+func PreconditionFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the record has been changed since you last fetched it, please fetch it again"
+	Error(w, http.StatusPreconditionFailed, message)
+}